@@ -0,0 +1,337 @@
+// Package alerts evaluates built-in alert conditions on the nodes of every
+// monitor cycle and sends notifications to webhooks.
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+)
+
+// Condition is the condition an alert fires on.
+type Condition string
+
+const (
+	Offline          Condition = "offline"
+	MonitoringFailed Condition = "monitoring_failed"
+	Malicious        Condition = "malicious"
+	IPTagged         Condition = "ip_tagged"
+	ServiceStopped   Condition = "service_stopped"
+	QualityLow       Condition = "quality_low"
+)
+
+type Status string
+
+const (
+	Firing   Status = "firing"
+	Resolved Status = "resolved"
+)
+
+// Alert is a condition that holds for a node.
+type Alert struct {
+	Condition Condition  `json:"condition"`
+	Status    Status     `json:"status"`
+	NodeID    string     `json:"node_id"`
+	NodeName  string     `json:"node_name"`
+	Service   string     `json:"service,omitempty"`
+	Message   string     `json:"message"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+}
+
+func (a Alert) key() string {
+	return a.NodeID + "/" + string(a.Condition) + "/" + a.Service
+}
+
+// Title is a short description of the alert.
+func (a Alert) Title() string {
+	return fmt.Sprintf("[%s] %s: %s", strings.ToUpper(string(a.Status)), a.NodeName, a.Condition)
+}
+
+// Notifier sends notifications about firing and resolved alerts.
+type Notifier interface {
+	Notify(alerts []Alert) error
+}
+
+// PartialError is returned by notifiers that deliver the alerts one by one if
+// only some of them were delivered.
+type PartialError struct {
+	// Delivered holds whether each alert was delivered.
+	Delivered []bool
+	Err       error
+}
+
+func (e *PartialError) Error() string {
+	var delivered int
+	for _, ok := range e.Delivered {
+		if ok {
+			delivered++
+		}
+	}
+	return fmt.Sprintf("delivered %d of %d alerts: %v", delivered, len(e.Delivered), e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+type Options struct {
+	// QualityThreshold is the quality below which a node is alerted, 0 disables the condition.
+	QualityThreshold float64
+	// For is how long a condition must hold before it is notified.
+	For      time.Duration
+	Silences []Silence
+}
+
+// Alerter keeps track of the alerts and which notifiers were notified about them.
+type Alerter struct {
+	notifiers []Notifier
+	opts      Options
+
+	alerts map[string]*tracked
+	// services holds every service seen on a node, to detect stopped services.
+	services map[string][]string
+}
+
+type tracked struct {
+	alert Alert
+	// notified holds whether each notifier was notified about the firing alert.
+	notified []bool
+}
+
+func New(notifiers []Notifier, opts Options) *Alerter {
+	return &Alerter{
+		notifiers: notifiers,
+		opts:      opts,
+		alerts:    make(map[string]*tracked),
+		services:  make(map[string][]string),
+	}
+}
+
+// Evaluate checks the conditions for all nodes and notifies about alerts that
+// started firing or were resolved since the last notification.
+func (a *Alerter) Evaluate(nodes []node.Node, now time.Time) {
+	current := make(map[string]Alert)
+	present := make(map[string]bool)
+	for _, n := range nodes {
+		for _, alert := range a.conditions(n) {
+			current[alert.key()] = alert
+		}
+		if !n.Deleted {
+			present[n.Identity] = true
+		}
+	}
+
+	// services of deleted nodes and nodes no longer reported are forgotten
+	for id := range a.services {
+		if !present[id] {
+			delete(a.services, id)
+		}
+	}
+
+	for key, alert := range current {
+		t, ok := a.alerts[key]
+		if !ok {
+			alert.Status = Firing
+			alert.StartsAt = now
+			a.alerts[key] = &tracked{alert: alert, notified: make([]bool, len(a.notifiers))}
+			continue
+		}
+
+		// a resolved alert firing again before the resolve was delivered continues
+		t.alert.Status = Firing
+		t.alert.EndsAt = nil
+		t.alert.Message = alert.Message
+	}
+
+	for key, t := range a.alerts {
+		if _, ok := current[key]; !ok && t.alert.Status == Firing {
+			t.alert.Status = Resolved
+			t.alert.EndsAt = &now
+		}
+	}
+
+	a.notify(now)
+}
+
+func (a *Alerter) conditions(n node.Node) []Alert {
+	if n.Deleted {
+		return nil
+	}
+
+	var alerts []Alert
+	add := func(condition Condition, service string, format string, args ...any) {
+		alerts = append(alerts, Alert{
+			Condition: condition,
+			NodeID:    n.Identity,
+			NodeName:  n.Name,
+			Service:   service,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	status := n.NodeStatus
+	if !status.Online {
+		add(Offline, "", "node is offline")
+	}
+	if status.MonitoringFailed {
+		add(MonitoringFailed, "", "monitoring of the node failed")
+	}
+	if n.Malicious {
+		add(Malicious, "", "node is tagged as malicious")
+	}
+	if n.IPTagged {
+		add(IPTagged, "", "ip address %s of the node is tagged", n.ExternalIP)
+	}
+
+	// offline nodes report neither quality nor services, so the alerts on them
+	// keep their state until the node is online again
+	if !status.Online {
+		for _, t := range a.alerts {
+			if t.alert.NodeID == n.Identity && t.alert.Status == Firing &&
+				(t.alert.Condition == QualityLow || t.alert.Condition == ServiceStopped) {
+				alerts = append(alerts, t.alert)
+			}
+		}
+	} else {
+		if a.opts.QualityThreshold > 0 && status.Quality < a.opts.QualityThreshold {
+			add(QualityLow, "", "quality %.2f is below %.2f", status.Quality, a.opts.QualityThreshold)
+		}
+
+		for _, service := range a.services[n.Identity] {
+			if !slices.Contains(status.ServiceTypes, service) {
+				add(ServiceStopped, service, "service %s stopped", service)
+			}
+		}
+		for _, service := range status.ServiceTypes {
+			if !slices.Contains(a.services[n.Identity], service) {
+				a.services[n.Identity] = append(a.services[n.Identity], service)
+			}
+		}
+	}
+
+	return alerts
+}
+
+// notify sends every notifier the alerts it was not notified about yet.
+// Notifications that fail are retried in the next cycle.
+func (a *Alerter) notify(now time.Time) {
+	keys := make([]string, 0, len(a.alerts))
+	for key := range a.alerts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for i, notifier := range a.notifiers {
+		var pending []*tracked
+		for _, key := range keys {
+			t := a.alerts[key]
+			switch {
+			case t.alert.Status == Firing && !t.notified[i]:
+				if now.Sub(t.alert.StartsAt) >= a.opts.For && !a.silenced(t.alert, now) {
+					pending = append(pending, t)
+				}
+			case t.alert.Status == Resolved && t.notified[i]:
+				pending = append(pending, t)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		alerts := make([]Alert, 0, len(pending))
+		for _, t := range pending {
+			alerts = append(alerts, t.alert)
+		}
+		delivered := make([]bool, len(pending))
+		if err := notifier.Notify(alerts); err != nil {
+			log.Warn().Err(err).Int("alerts", len(alerts)).Msg("failed to send alert notification")
+
+			var partial *PartialError
+			if !errors.As(err, &partial) || len(partial.Delivered) != len(pending) {
+				continue
+			}
+			delivered = partial.Delivered
+		} else {
+			for j := range delivered {
+				delivered[j] = true
+			}
+		}
+
+		for j, t := range pending {
+			if delivered[j] {
+				t.notified[i] = t.alert.Status == Firing
+			}
+		}
+	}
+
+	// resolved alerts are kept until every notifier knows about the resolve
+	for key, t := range a.alerts {
+		if t.alert.Status == Resolved && !slices.Contains(t.notified, true) {
+			delete(a.alerts, key)
+		}
+	}
+}
+
+func (a *Alerter) silenced(alert Alert, now time.Time) bool {
+	for _, s := range a.opts.Silences {
+		if s.matches(alert, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Silence suppresses notifications about firing alerts of a node.
+type Silence struct {
+	// Node is the identity or name of the node.
+	Node string
+	// Until is the end of the silence, the silence is permanent if it is zero.
+	Until time.Time
+}
+
+// ParseSilence parses a silence of the form node or node=until, where until is
+// a RFC 3339 time.
+func ParseSilence(s string) (Silence, error) {
+	nodeName, until, found := strings.Cut(strings.TrimSpace(s), "=")
+	if nodeName == "" {
+		return Silence{}, fmt.Errorf("invalid silence: %s: missing node", s)
+	}
+
+	silence := Silence{Node: nodeName}
+	if found {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return Silence{}, fmt.Errorf("invalid silence: %s: %w", s, err)
+		}
+		silence.Until = t
+	}
+	return silence, nil
+}
+
+// ParseSilences parses the silences, see ParseSilence.
+func ParseSilences(silences []string) ([]Silence, error) {
+	parsed := make([]Silence, 0, len(silences))
+	for _, s := range silences {
+		silence, err := ParseSilence(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, silence)
+	}
+	return parsed, nil
+}
+
+func (s Silence) matches(alert Alert, now time.Time) bool {
+	if s.Node != alert.NodeID && s.Node != alert.NodeName {
+		return false
+	}
+	return s.Until.IsZero() || now.Before(s.Until)
+}
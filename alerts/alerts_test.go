@@ -0,0 +1,242 @@
+package alerts
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+)
+
+// standIn is a local http stand-in for a webhook endpoint that records the
+// requests it receives.
+type standIn struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []request
+	// fail makes the stand-in answer requests with the given index with an error.
+	fail map[int]bool
+}
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+func newStandIn(t *testing.T) *standIn {
+	s := &standIn{fail: make(map[int]bool)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request: %v", err)
+		}
+
+		index := len(s.requests)
+		s.requests = append(s.requests, request{header: r.Header.Clone(), body: body})
+		if s.fail[index] {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *standIn) received() []request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]request(nil), s.requests...)
+}
+
+// alerts decodes the generic json payloads received since the given request.
+func (s *standIn) alerts(t *testing.T, since int) []Alert {
+	t.Helper()
+	var alerts []Alert
+	for _, r := range s.received()[since:] {
+		var payload struct {
+			Alerts []Alert `json:"alerts"`
+		}
+		if err := json.Unmarshal(r.body, &payload); err != nil {
+			t.Fatalf("failed to decode payload %s: %v", r.body, err)
+		}
+		alerts = append(alerts, payload.Alerts...)
+	}
+	return alerts
+}
+
+func newAlerter(t *testing.T, url string, opts Options) *Alerter {
+	t.Helper()
+	webhook, err := ParseWebhook(url)
+	if err != nil {
+		t.Fatalf("failed to parse webhook: %v", err)
+	}
+	return New([]Notifier{webhook}, opts)
+}
+
+func testNode(online bool, quality float64, services ...string) node.Node {
+	return node.Node{
+		Identity: "0x1",
+		Name:     "node-1",
+		NodeStatus: node.Status{
+			Online:       online,
+			Quality:      quality,
+			ServiceTypes: services,
+		},
+	}
+}
+
+func TestFiringAndResolve(t *testing.T) {
+	server := newStandIn(t)
+	a := newAlerter(t, server.URL, Options{})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a.Evaluate([]node.Node{testNode(false, 0)}, now)
+	alerts := server.alerts(t, 0)
+	if len(alerts) != 1 || alerts[0].Condition != Offline || alerts[0].Status != Firing {
+		t.Fatalf("got %+v, want a firing offline alert", alerts)
+	}
+
+	// the alert is only notified once while it fires
+	a.Evaluate([]node.Node{testNode(false, 0)}, now.Add(time.Minute))
+	if n := len(server.received()); n != 1 {
+		t.Fatalf("got %d notifications, want 1", n)
+	}
+
+	a.Evaluate([]node.Node{testNode(true, 2)}, now.Add(time.Minute*2))
+	alerts = server.alerts(t, 1)
+	if len(alerts) != 1 || alerts[0].Condition != Offline || alerts[0].Status != Resolved || alerts[0].EndsAt == nil {
+		t.Fatalf("got %+v, want a resolved offline alert", alerts)
+	}
+
+	a.Evaluate([]node.Node{testNode(true, 2)}, now.Add(time.Minute*3))
+	if n := len(server.received()); n != 2 {
+		t.Fatalf("got %d notifications, want 2", n)
+	}
+}
+
+func TestFor(t *testing.T) {
+	server := newStandIn(t)
+	a := newAlerter(t, server.URL, Options{For: time.Minute * 10})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a.Evaluate([]node.Node{testNode(false, 0)}, now)
+	a.Evaluate([]node.Node{testNode(false, 0)}, now.Add(time.Minute*5))
+	if n := len(server.received()); n != 0 {
+		t.Fatalf("got %d notifications before the alert held for long enough, want 0", n)
+	}
+
+	a.Evaluate([]node.Node{testNode(false, 0)}, now.Add(time.Minute*10))
+	if n := len(server.received()); n != 1 {
+		t.Fatalf("got %d notifications, want 1", n)
+	}
+
+	// alerts resolved before they were notified are not notified at all
+	a.Evaluate([]node.Node{testNode(true, 2), {Identity: "0x2", Name: "node-2"}}, now.Add(time.Minute*11))
+	a.Evaluate([]node.Node{testNode(true, 2)}, now.Add(time.Minute*12))
+	if n := len(server.alerts(t, 1)); n != 1 {
+		t.Fatalf("got %d alerts, want only the resolved offline alert", n)
+	}
+}
+
+func TestSilence(t *testing.T) {
+	server := newStandIn(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := newAlerter(t, server.URL, Options{Silences: []Silence{{Node: "node-1", Until: now.Add(time.Hour)}}})
+
+	a.Evaluate([]node.Node{testNode(false, 0)}, now)
+	if n := len(server.received()); n != 0 {
+		t.Fatalf("got %d notifications for a silenced node, want 0", n)
+	}
+
+	// the alert is notified once the silence ended
+	a.Evaluate([]node.Node{testNode(false, 0)}, now.Add(time.Hour))
+	alerts := server.alerts(t, 0)
+	if len(alerts) != 1 || alerts[0].Status != Firing {
+		t.Fatalf("got %+v, want a firing alert after the silence", alerts)
+	}
+}
+
+func TestOfflineKeepsQualityAndServices(t *testing.T) {
+	server := newStandIn(t)
+	a := newAlerter(t, server.URL, Options{QualityThreshold: 1})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a.Evaluate([]node.Node{testNode(true, 2, "wireguard", "scraping")}, now)
+	a.Evaluate([]node.Node{testNode(true, 0.5, "wireguard")}, now.Add(time.Minute))
+	if n := len(server.alerts(t, 0)); n != 2 {
+		t.Fatalf("got %d alerts, want quality low and service stopped", n)
+	}
+
+	// an offline node reports neither quality nor services
+	a.Evaluate([]node.Node{testNode(false, 0)}, now.Add(time.Minute*2))
+	alerts := server.alerts(t, 1)
+	if len(alerts) != 1 || alerts[0].Condition != Offline || alerts[0].Status != Firing {
+		t.Fatalf("got %+v, want only a firing offline alert", alerts)
+	}
+
+	a.Evaluate([]node.Node{testNode(true, 2, "wireguard", "scraping")}, now.Add(time.Minute*3))
+	alerts = server.alerts(t, 2)
+	if len(alerts) != 3 {
+		t.Fatalf("got %+v, want three resolved alerts", alerts)
+	}
+	for _, alert := range alerts {
+		if alert.Status != Resolved {
+			t.Errorf("got %+v, want a resolved alert", alert)
+		}
+	}
+}
+
+func TestServicesPruned(t *testing.T) {
+	server := newStandIn(t)
+	a := newAlerter(t, server.URL, Options{})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a.Evaluate([]node.Node{testNode(true, 2, "wireguard")}, now)
+	deleted := testNode(true, 2, "wireguard")
+	deleted.Deleted = true
+	a.Evaluate([]node.Node{deleted}, now.Add(time.Minute))
+	if _, ok := a.services["0x1"]; ok {
+		t.Errorf("services of a deleted node are kept")
+	}
+
+	a.Evaluate([]node.Node{testNode(true, 2, "wireguard")}, now.Add(time.Minute*2))
+	a.Evaluate(nil, now.Add(time.Minute*3))
+	if _, ok := a.services["0x1"]; ok {
+		t.Errorf("services of a node missing from the api are kept")
+	}
+}
+
+func TestNtfyPartialFailure(t *testing.T) {
+	server := newStandIn(t)
+	server.fail[1] = true
+	a := newAlerter(t, "ntfy="+server.URL, Options{QualityThreshold: 1})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a.Evaluate([]node.Node{{Identity: "0x1", Name: "node-1", Malicious: true}}, now)
+	if n := len(server.received()); n != 2 {
+		t.Fatalf("got %d messages, want 2", n)
+	}
+
+	// only the alert that failed is sent again
+	a.Evaluate([]node.Node{{Identity: "0x1", Name: "node-1", Malicious: true}}, now.Add(time.Minute))
+	requests := server.received()
+	if len(requests) != 3 {
+		t.Fatalf("got %d messages, want 3", len(requests))
+	}
+	if requests[2].header.Get("Title") != requests[1].header.Get("Title") {
+		t.Errorf("got %q, want the failed alert %q to be retried",
+			requests[2].header.Get("Title"), requests[1].header.Get("Title"))
+	}
+
+	a.Evaluate([]node.Node{{Identity: "0x1", Name: "node-1", Malicious: true}}, now.Add(time.Minute*2))
+	if n := len(server.received()); n != 3 {
+		t.Fatalf("got %d messages, want 3", n)
+	}
+}
@@ -0,0 +1,181 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Format is the payload format of a webhook.
+type Format string
+
+const (
+	// JSON posts the alerts as generic json.
+	JSON    Format = "json"
+	Slack   Format = "slack"
+	Discord Format = "discord"
+	// Ntfy posts every alert as a message to a ntfy topic url.
+	Ntfy Format = "ntfy"
+)
+
+var Formats = []Format{JSON, Slack, Discord, Ntfy}
+
+const (
+	webhookTimeout = time.Second * 10
+	// maximum length of a Discord message
+	discordMaxLength = 2000
+)
+
+// Webhook sends notifications to an http endpoint.
+type Webhook struct {
+	Format Format
+	URL    string
+	client *http.Client
+}
+
+// ParseWebhook parses a webhook of the form format=url or url, which posts
+// generic json.
+func ParseWebhook(s string) (*Webhook, error) {
+	format, rawURL := JSON, strings.TrimSpace(s)
+	if prefix, rest, found := strings.Cut(rawURL, "="); found && slices.Contains(Formats, Format(prefix)) {
+		format, rawURL = Format(prefix), rest
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid webhook url: %s: scheme must be http or https", rawURL)
+	}
+
+	return &Webhook{
+		Format: format,
+		URL:    rawURL,
+		client: &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+// NewWebhooks parses the webhooks as notifiers.
+func NewWebhooks(webhooks []string) ([]Notifier, error) {
+	notifiers := make([]Notifier, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		w, err := ParseWebhook(webhook)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, w)
+	}
+	return notifiers, nil
+}
+
+func (w *Webhook) Notify(alerts []Alert) error {
+	switch w.Format {
+	case Slack:
+		return w.post("application/json", map[string]string{"text": messages(alerts, slackLine)}, nil)
+	case Discord:
+		content := []rune(messages(alerts, discordLine))
+		if len(content) > discordMaxLength {
+			content = append(content[:discordMaxLength-1], '…')
+		}
+		return w.post("application/json", map[string]string{"content": string(content)}, nil)
+	case Ntfy:
+		return w.notifyNtfy(alerts)
+	default:
+		return w.post("application/json", map[string][]Alert{"alerts": alerts}, nil)
+	}
+}
+
+// notifyNtfy posts every alert as its own message. Alerts that could not be
+// delivered are reported in a PartialError, so only they are retried.
+func (w *Webhook) notifyNtfy(alerts []Alert) error {
+	delivered := make([]bool, len(alerts))
+	var errs []error
+	for i, alert := range alerts {
+		if err := w.postNtfy(alert); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delivered[i] = true
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &PartialError{Delivered: delivered, Err: errors.Join(errs...)}
+}
+
+func (w *Webhook) postNtfy(alert Alert) error {
+	headers := map[string]string{
+		"Title":    alert.Title(),
+		"Tags":     "rotating_light",
+		"Priority": "high",
+	}
+	if alert.Status == Resolved {
+		headers["Tags"] = "white_check_mark"
+		headers["Priority"] = "default"
+	}
+	return w.post("text/plain", []byte(alert.Message), headers)
+}
+
+// post sends the body, which is encoded as json unless it is a byte slice.
+func (w *Webhook) post(contentType string, body any, headers map[string]string) error {
+	data, ok := body.([]byte)
+	if !ok {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s webhook: %w", w.Format, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%s webhook: unexpected status: %s", w.Format, res.Status)
+	}
+	return nil
+}
+
+func messages(alerts []Alert, line func(Alert) string) string {
+	lines := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		lines = append(lines, line(alert))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func slackLine(alert Alert) string {
+	emoji := ":rotating_light:"
+	if alert.Status == Resolved {
+		emoji = ":white_check_mark:"
+	}
+	return fmt.Sprintf("%s *%s* %s", emoji, alert.Title(), alert.Message)
+}
+
+func discordLine(alert Alert) string {
+	emoji := "🚨"
+	if alert.Status == Resolved {
+		emoji = "✅"
+	}
+	return fmt.Sprintf("%s **%s** %s", emoji, alert.Title(), alert.Message)
+}
@@ -0,0 +1,137 @@
+package alerts
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var testAlerts = []Alert{
+	{
+		Condition: Offline,
+		Status:    Firing,
+		NodeID:    "0x1",
+		NodeName:  "node-1",
+		Message:   "node is offline",
+		StartsAt:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		Condition: ServiceStopped,
+		Status:    Resolved,
+		NodeID:    "0x2",
+		NodeName:  "node-2",
+		Service:   "wireguard",
+		Message:   "service wireguard stopped",
+		StartsAt:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+func notify(t *testing.T, format Format, alerts []Alert) []request {
+	t.Helper()
+	server := newStandIn(t)
+	webhook, err := ParseWebhook(string(format) + "=" + server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse webhook: %v", err)
+	}
+	if err := webhook.Notify(alerts); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	return server.received()
+}
+
+func TestJSONPayload(t *testing.T) {
+	requests := notify(t, JSON, testAlerts)
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+
+	var payload struct {
+		Alerts []Alert `json:"alerts"`
+	}
+	if err := json.Unmarshal(requests[0].body, &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if len(payload.Alerts) != 2 || payload.Alerts[1].Service != "wireguard" || payload.Alerts[1].Status != Resolved {
+		t.Errorf("got %+v, want the test alerts", payload.Alerts)
+	}
+	if got := requests[0].header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("got content type %q, want application/json", got)
+	}
+}
+
+func TestSlackPayload(t *testing.T) {
+	requests := notify(t, Slack, testAlerts)
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(requests[0].body, &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	want := ":rotating_light: *[FIRING] node-1: offline* node is offline\n" +
+		":white_check_mark: *[RESOLVED] node-2: service_stopped* service wireguard stopped"
+	if payload["text"] != want {
+		t.Errorf("got text %q, want %q", payload["text"], want)
+	}
+}
+
+func TestDiscordPayload(t *testing.T) {
+	requests := notify(t, Discord, testAlerts)
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(requests[0].body, &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	want := "🚨 **[FIRING] node-1: offline** node is offline\n" +
+		"✅ **[RESOLVED] node-2: service_stopped** service wireguard stopped"
+	if payload["content"] != want {
+		t.Errorf("got content %q, want %q", payload["content"], want)
+	}
+}
+
+func TestDiscordPayloadTruncated(t *testing.T) {
+	alert := testAlerts[0]
+	alert.Message = strings.Repeat("ä", discordMaxLength)
+	requests := notify(t, Discord, []Alert{alert})
+
+	var payload map[string]string
+	if err := json.Unmarshal(requests[0].body, &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if n := utf8.RuneCountInString(payload["content"]); n != discordMaxLength {
+		t.Errorf("got content of %d characters, want %d", n, discordMaxLength)
+	}
+	if !strings.HasSuffix(payload["content"], "…") {
+		t.Errorf("truncated content does not end with an ellipsis")
+	}
+}
+
+func TestNtfyPayload(t *testing.T) {
+	requests := notify(t, Ntfy, testAlerts)
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want one per alert", len(requests))
+	}
+
+	for i, want := range []struct {
+		title    string
+		tags     string
+		priority string
+		body     string
+	}{
+		{"[FIRING] node-1: offline", "rotating_light", "high", "node is offline"},
+		{"[RESOLVED] node-2: service_stopped", "white_check_mark", "default", "service wireguard stopped"},
+	} {
+		r := requests[i]
+		if r.header.Get("Title") != want.title || r.header.Get("Tags") != want.tags ||
+			r.header.Get("Priority") != want.priority || string(r.body) != want.body {
+			t.Errorf("got title %q, tags %q, priority %q and body %q, want %+v", r.header.Get("Title"),
+				r.header.Get("Tags"), r.header.Get("Priority"), r.body, want)
+		}
+	}
+}
@@ -5,25 +5,50 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/sch8ill/mystprom/api/client"
 )
 
 const (
-	BaseURL = "https://api.coingecko.com"
-	Path    = "/api/v3/simple/price?ids=mysterium&vs_currencies=EUR,USD"
+	BaseURL    = "https://api.coingecko.com"
+	ProBaseURL = "https://pro-api.coingecko.com"
+	Path       = "/api/v3/simple/price"
 
+	DemoAPIKeyHeader = "x-cg-demo-api-key"
+	ProAPIKeyHeader  = "x-cg-pro-api-key"
+
+	Name       = "coingecko"
 	MystSymbol = "MYST"
 	MystID     = "mysterium"
 )
 
 type Coingecko struct {
-	client *client.HttpClient
+	client     *client.HttpClient
+	currencies []string
+}
+
+type MarketData struct {
+	Price     float64
+	Change24h float64
+	MarketCap float64
+	Volume24h float64
 }
 
-func New() (*Coingecko, error) {
-	c, err := client.New(BaseURL)
+func New(currencies []string) (*Coingecko, error) {
+	return NewWithAPIKey(currencies, "", false)
+}
+
+// NewWithAPIKey creates a client authenticating with a demo api key, or a pro
+// api key against the pro api if pro is set. An empty key uses the public api.
+func NewWithAPIKey(currencies []string, apiKey string, pro bool) (*Coingecko, error) {
+	baseURL := BaseURL
+	if pro {
+		baseURL = ProBaseURL
+	}
+
+	c, err := client.New(baseURL)
 	if err != nil {
 		return nil, err
 	}
@@ -31,11 +56,48 @@ func New() (*Coingecko, error) {
 	c.SetHeader("Content-Type", "application/json")
 	c.SetHeader("Accept", "application/json")
 
-	return &Coingecko{client: c}, nil
+	if apiKey != "" {
+		if pro {
+			c.SetHeader(ProAPIKeyHeader, apiKey)
+		} else {
+			c.SetHeader(DemoAPIKeyHeader, apiKey)
+		}
+	}
+
+	return &Coingecko{client: c, currencies: currencies}, nil
+}
+
+func (c *Coingecko) Name() string {
+	return Name
 }
 
 func (c *Coingecko) MystPrices() (map[string]float64, error) {
-	res, err := c.client.Get(Path)
+	market, err := c.MystMarketData()
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64)
+	for currency, data := range market {
+		prices[currency] = data.Price
+	}
+	prices[MystSymbol] = 1
+
+	return prices, nil
+}
+
+// MystMarketData returns the price, 24h change, market cap and 24h volume of the
+// MYST token by currency. Currencies unknown to coingecko are omitted.
+func (c *Coingecko) MystMarketData() (map[string]MarketData, error) {
+	query := url.Values{
+		"ids":                 {MystID},
+		"vs_currencies":       {strings.ToLower(strings.Join(c.currencies, ","))},
+		"include_market_cap":  {"true"},
+		"include_24hr_vol":    {"true"},
+		"include_24hr_change": {"true"},
+	}
+
+	res, err := c.client.Get(fmt.Sprintf("%s?%s", Path, query.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -54,12 +116,21 @@ func (c *Coingecko) MystPrices() (map[string]float64, error) {
 		return nil, fmt.Errorf("response does not contain: %s", MystID)
 	}
 
-	// key to upper
-	prices := make(map[string]float64)
-	for key, val := range data[MystID] {
-		prices[strings.ToUpper(key)] = val
+	market := make(map[string]MarketData)
+	for _, currency := range c.currencies {
+		key := strings.ToLower(currency)
+		price, ok := data[MystID][key]
+		if !ok {
+			continue
+		}
+
+		market[strings.ToUpper(currency)] = MarketData{
+			Price:     price,
+			Change24h: data[MystID][key+"_24h_change"],
+			MarketCap: data[MystID][key+"_market_cap"],
+			Volume24h: data[MystID][key+"_24h_vol"],
+		}
 	}
-	prices[MystSymbol] = 1
 
-	return prices, nil
+	return market, nil
 }
@@ -4,6 +4,7 @@ package cryptocompare
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/sch8ill/mystprom/api/client"
@@ -13,16 +14,16 @@ const (
 	BaseURL   = "https://min-api.cryptocompare.com"
 	PricePath = "/data/price"
 
+	Name       = "cryptocompare"
 	MystSymbol = "MYST"
 )
 
-var Currencies = []string{"EUR", "USD"}
-
 type CryptoCompare struct {
-	client *client.HttpClient
+	client     *client.HttpClient
+	currencies []string
 }
 
-func New() (*CryptoCompare, error) {
+func New(currencies []string) (*CryptoCompare, error) {
 	c, err := client.New(BaseURL)
 	if err != nil {
 		return nil, err
@@ -31,7 +32,11 @@ func New() (*CryptoCompare, error) {
 	c.SetHeader("Content-Type", "application/json")
 	c.SetHeader("Accept", "application/json")
 
-	return &CryptoCompare{client: c}, nil
+	return &CryptoCompare{client: c, currencies: currencies}, nil
+}
+
+func (c *CryptoCompare) Name() string {
+	return Name
 }
 
 func (c *CryptoCompare) Prices(symbol string, currencies []string) (map[string]float64, error) {
@@ -40,6 +45,11 @@ func (c *CryptoCompare) Prices(symbol string, currencies []string) (map[string]f
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
 
 	prices := make(map[string]float64)
 	if err := json.NewDecoder(res.Body).Decode(&prices); err != nil {
@@ -51,7 +61,7 @@ func (c *CryptoCompare) Prices(symbol string, currencies []string) (map[string]f
 }
 
 func (c *CryptoCompare) MystPrices() (map[string]float64, error) {
-	prices, err := c.Prices(MystSymbol, Currencies)
+	prices, err := c.Prices(MystSymbol, c.currencies)
 	if err != nil {
 		return nil, err
 	}
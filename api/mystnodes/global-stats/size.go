@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var sizeUnits = map[string]float64{
+	"B":   1,
+	"KB":  math.Pow(1000, 1),
+	"MB":  math.Pow(1000, 2),
+	"GB":  math.Pow(1000, 3),
+	"TB":  math.Pow(1000, 4),
+	"PB":  math.Pow(1000, 5),
+	"EB":  math.Pow(1000, 6),
+	"KIB": math.Pow(1024, 1),
+	"MIB": math.Pow(1024, 2),
+	"GIB": math.Pow(1024, 3),
+	"TIB": math.Pow(1024, 4),
+	"PIB": math.Pow(1024, 5),
+	"EIB": math.Pow(1024, 6),
+}
+
+// ParseSize parses a human-readable size such as "1.23 PB" or "512TiB" into
+// bytes. Decimal (KB, MB, ...) and binary (KiB, MiB, ...) units from B up to
+// EB are supported, units are matched case-insensitively.
+func ParseSize(size string) (float64, error) {
+	size = strings.TrimSpace(size)
+
+	// the unit is the trailing run of letters, the value may contain an exponent
+	i := strings.LastIndexFunc(size, func(r rune) bool { return !unicode.IsLetter(r) }) + 1
+	if i == len(size) {
+		return 0, fmt.Errorf("missing unit in size: %q", size)
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(size[:i]), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size: %q: %w", size, err)
+	}
+
+	multiplier, ok := sizeUnits[strings.ToUpper(size[i:])]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit in size: %q", size)
+	}
+
+	return value * multiplier, nil
+}
@@ -0,0 +1,39 @@
+package stats
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size  string
+		want  float64
+		valid bool
+	}{
+		{size: "1.23 PB", want: 1.23e15, valid: true},
+		{size: "512TiB", want: 512 * (1 << 40), valid: true},
+		{size: " 10 mb ", want: 10e6, valid: true},
+		{size: "1.2e3 GB", want: 1.2e12, valid: true},
+		{size: "1.2e3GB", want: 1.2e12, valid: true},
+		{size: "3 EB", want: 3e18, valid: true},
+		{size: "42", valid: false},
+		{size: "GB", valid: false},
+		{size: "1.2 XB", valid: false},
+		{size: "1.2e GB", valid: false},
+	}
+	for _, test := range tests {
+		t.Run(test.size, func(t *testing.T) {
+			got, err := ParseSize(test.size)
+			if !test.valid {
+				if err == nil {
+					t.Errorf("expected an error, got %g", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse size: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("got %g bytes, want %g", got, test.want)
+			}
+		})
+	}
+}
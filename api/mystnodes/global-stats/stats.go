@@ -1,12 +1,69 @@
 package stats
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const GlobalCategory = "global"
+
 type Stat struct {
-	Name  string  `json:"name"`
-	Value *Global `json:"value"`
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
 }
 
 type Global struct {
-	TotalNodes     int `json:"totalNodes,string"`
-	TotalTraffic   int `json:"totalTraffic"`
-	TotalCountries int `json:"totalCountries,string"`
+	TotalNodes     int
+	TotalTraffic   float64 // bytes
+	TotalCountries int
+}
+
+// Global parses the value of a stat of the global category.
+func (s *Stat) Global() (*Global, error) {
+	var raw struct {
+		TotalNodes     int    `json:"totalNodes,string"`
+		TotalTraffic   string `json:"totalTraffic"`
+		TotalCountries int    `json:"totalCountries,string"`
+	}
+	if err := json.Unmarshal(s.Value, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode global stats: %w", err)
+	}
+
+	traffic, err := ParseSize(raw.TotalTraffic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse total traffic: %w", err)
+	}
+
+	return &Global{
+		TotalNodes:     raw.TotalNodes,
+		TotalTraffic:   traffic,
+		TotalCountries: raw.TotalCountries,
+	}, nil
+}
+
+// Values returns all numeric values of the stat by key. Numbers encoded as
+// strings are parsed, sizes such as "1.5 PB" are converted to bytes and all
+// other values are skipped.
+func (s *Stat) Values() (map[string]float64, error) {
+	raw := make(map[string]any)
+	if err := json.Unmarshal(s.Value, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode %s stats: %w", s.Name, err)
+	}
+
+	values := make(map[string]float64)
+	for key, value := range raw {
+		switch v := value.(type) {
+		case float64:
+			values[key] = v
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				values[key] = f
+			} else if f, err := ParseSize(v); err == nil {
+				values[key] = f
+			}
+		}
+	}
+
+	return values, nil
 }
@@ -6,8 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"math"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -32,7 +32,7 @@ const (
 	RewardRanksPath   = "/api/v2/reward-program/ranks"
 	RewardPointsPath  = "/api/v2/reward-program/points"
 	RewardStatsPath   = "/api/v2/reward-program/stats"
-	GlobalStatsPath   = "/api/v2/global-stats?categories=global"
+	GlobalStatsPath   = "/api/v2/global-stats"
 	AccountInfoPath   = "/api/v2/me"
 )
 
@@ -324,43 +324,21 @@ func (m *MystAPI) RewardRanks() ([]rewards.User, error) {
 	return ranks, nil
 }
 
-func (m *MystAPI) GlobalStats() (*stats.Global, error) {
+func (m *MystAPI) GlobalStats(categories []string) ([]stats.Stat, error) {
 	if err := m.authenticate(); err != nil {
 		return nil, err
 	}
 
-	type stat struct {
-		Value struct {
-			TotalNodes     int    `json:"totalNodes,string"`
-			TotalTraffic   string `json:"totalTraffic"`
-			TotalCountries int    `json:"totalCountries,string"`
-		} `json:"value"`
-	}
-
-	res, err := m.client.Get(GlobalStatsPath)
+	query := url.Values{"categories": {strings.Join(categories, ",")}}
+	res, err := m.client.Get(fmt.Sprintf("%s?%s", GlobalStatsPath, query.Encode()))
 	if err != nil {
 		return nil, err
 	}
 
-	rawStat := []stat{}
-	if err := m.parseResponse(res, &rawStat); err != nil {
-		return nil, err
-	}
-
-	if len(rawStat) != 1 {
-		return nil, fmt.Errorf("unexpected array length: %d", len(rawStat))
-	}
-
-	s := &stats.Global{
-		TotalNodes:     rawStat[0].Value.TotalNodes,
-		TotalCountries: rawStat[0].Value.TotalCountries,
-	}
-	rawTotalTraffic, _ := strings.CutSuffix(rawStat[0].Value.TotalTraffic, " PB")
-	totalTraffic, err := strconv.ParseFloat(rawTotalTraffic, 64)
-	if err != nil {
+	var s []stats.Stat
+	if err := m.parseResponse(res, &s); err != nil {
 		return nil, err
 	}
-	s.TotalTraffic = int(totalTraffic * math.Pow10(13))
 
 	return s, nil
 }
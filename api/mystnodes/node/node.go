@@ -10,11 +10,12 @@ type Nodes struct {
 	Total int    `json:"total"`
 }
 
+// Node is a node of the account. Fields the api may return as null are pointers.
 type Node struct {
 	ID               string            `json:"id"`
 	UserID           string            `json:"userId"`
 	TermsVersion     string            `json:"termsVersion,omitempty"`
-	TermsAcceptedAt  time.Time         `json:"termsAcceptedAt,omitempty"`
+	TermsAcceptedAt  *time.Time        `json:"termsAcceptedAt,omitempty"`
 	Whitelist        string            `json:"whitelist,omitempty"`
 	LocalIP          string            `json:"localIp"`
 	ExternalIP       string            `json:"externalIp"`
@@ -26,7 +27,7 @@ type Node struct {
 	Identity         string            `json:"identity"`
 	Malicious        bool              `json:"malicious"`
 	Name             string            `json:"name"`
-	AvailableAt      time.Time         `json:"availableAt,omitempty"`
+	AvailableAt      *time.Time        `json:"availableAt,omitempty"`
 	CreatedAt        time.Time         `json:"createdAt"`
 	UpdatedAt        time.Time         `json:"updatedAt"`
 	Deleted          bool              `json:"deleted"`
@@ -57,19 +58,19 @@ func (n *Node) UnmarshalJSON(data []byte) error {
 }
 
 type Status struct {
-	ID                     string    `json:"id"`
-	NodeID                 string    `json:"nodeId"`
-	MonitoringFailed       bool      `json:"monitoringFailed"`
-	MonitoringFailedLastAt time.Time `json:"monitoringFailedLastAt"`
-	Online                 bool      `json:"online"`
-	OnlineLastAt           time.Time `json:"onlineLastAt"`
-	CreatedAt              time.Time `json:"createdAt"`
-	UpdatedAt              time.Time `json:"updatedAt"`
-	IPCategory             string    `json:"ipCategory"`
-	Location               string    `json:"location"`
-	Quality                float64   `json:"quality"`
-	ServiceTypes           []string  `json:"serviceTypes"`
-	FirstSuccessfulOnline  time.Time `json:"firstSuccessfulOnlineAt"`
+	ID                     string     `json:"id"`
+	NodeID                 string     `json:"nodeId"`
+	MonitoringFailed       bool       `json:"monitoringFailed"`
+	MonitoringFailedLastAt *time.Time `json:"monitoringFailedLastAt"`
+	Online                 bool       `json:"online"`
+	OnlineLastAt           *time.Time `json:"onlineLastAt"`
+	CreatedAt              time.Time  `json:"createdAt"`
+	UpdatedAt              time.Time  `json:"updatedAt"`
+	IPCategory             string     `json:"ipCategory"`
+	Location               string     `json:"location"`
+	Quality                float64    `json:"quality"`
+	ServiceTypes           []string   `json:"serviceTypes"`
+	FirstSuccessfulOnline  *time.Time `json:"firstSuccessfulOnlineAt"`
 }
 
 type Earnings struct {
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+
+	"github.com/sch8ill/mystprom/alerts"
+	"github.com/sch8ill/mystprom/config"
+)
+
+func alertsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "alerts",
+		Usage: "Manage the built-in alerting.",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "test",
+				Usage:  "Send a firing and a resolved test alert to the webhooks.",
+				Action: testAlerts,
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:     config.AlertWebhookFlag,
+						Usage:    "webhook urls, optionally prefixed with the format (json, slack, discord, ntfy)",
+						EnvVars:  []string{"MYSTPROM_ALERT_WEBHOOK"},
+						Required: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func testAlerts(ctx *cli.Context) error {
+	notifiers, err := alerts.NewWebhooks(ctx.StringSlice(config.AlertWebhookFlag))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	alert := alerts.Alert{
+		Condition: alerts.Offline,
+		Status:    alerts.Firing,
+		NodeID:    "0x0000000000000000000000000000000000000000",
+		NodeName:  "mystprom-test",
+		Message:   "test alert sent by mystprom",
+		StartsAt:  now,
+	}
+
+	for _, notifier := range notifiers {
+		if err := notifier.Notify([]alerts.Alert{alert}); err != nil {
+			return fmt.Errorf("failed to send firing alert: %w", err)
+		}
+	}
+
+	alert.Status = alerts.Resolved
+	alert.EndsAt = &now
+	for _, notifier := range notifiers {
+		if err := notifier.Notify([]alerts.Alert{alert}); err != nil {
+			return fmt.Errorf("failed to send resolved alert: %w", err)
+		}
+	}
+
+	log.Info().Int("webhooks", len(notifiers)).Msg("Test alerts sent")
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+
+	"github.com/sch8ill/mystprom/dashboard"
+	"github.com/sch8ill/mystprom/metrics"
+)
+
+const (
+	dashboardTitleFlag        = "title"
+	dashboardIncludeFlag      = "include"
+	dashboardExcludeFlag      = "exclude"
+	dashboardSchemeFlag       = "scheme"
+	dashboardAccountLabelFlag = "account-label"
+	dashboardGroupLabelFlag   = "group-label"
+	dashboardOutputFlag       = "output"
+)
+
+func dashboardCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "dashboard",
+		Usage: "Generate Grafana dashboards for the exported metrics.",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "generate",
+				Usage:  "Generate a Grafana dashboard json from the metric catalog.",
+				Action: generateDashboard,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  dashboardTitleFlag,
+						Usage: "title of the dashboard",
+						Value: "Mysterium nodes",
+					},
+					&cli.StringSliceFlag{
+						Name:  dashboardIncludeFlag,
+						Usage: "sections to include (nodes, earnings, sessions, rewards, global)",
+						Value: cli.NewStringSlice(dashboard.AllSections...),
+					},
+					&cli.StringSliceFlag{
+						Name:  dashboardExcludeFlag,
+						Usage: "sections to exclude",
+					},
+					&cli.StringFlag{
+						Name:  dashboardSchemeFlag,
+						Usage: "metric scheme of the exporter (v1, v2)",
+						Value: metrics.SchemeV1,
+					},
+					&cli.StringFlag{
+						Name:  dashboardAccountLabelFlag,
+						Usage: "target label the account variable filters by",
+						Value: "account",
+					},
+					&cli.StringFlag{
+						Name:  dashboardGroupLabelFlag,
+						Usage: "target label the group variable filters by",
+						Value: "group",
+					},
+					&cli.StringFlag{
+						Name:    dashboardOutputFlag,
+						Aliases: []string{"o"},
+						Usage:   "file to write the dashboard to instead of stdout",
+					},
+				},
+			},
+		},
+	}
+}
+
+func generateDashboard(ctx *cli.Context) error {
+	scheme := ctx.String(dashboardSchemeFlag)
+	if scheme != metrics.SchemeV1 && scheme != metrics.SchemeV2 {
+		return fmt.Errorf("unsupported scheme: %s", scheme)
+	}
+
+	var sections []string
+	for _, section := range ctx.StringSlice(dashboardIncludeFlag) {
+		if !slices.Contains(ctx.StringSlice(dashboardExcludeFlag), section) {
+			sections = append(sections, section)
+		}
+	}
+
+	d, err := dashboard.Generate(dashboard.Options{
+		Title:        ctx.String(dashboardTitleFlag),
+		Sections:     sections,
+		Scheme:       scheme,
+		AccountLabel: ctx.String(dashboardAccountLabelFlag),
+		GroupLabel:   ctx.String(dashboardGroupLabelFlag),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate dashboard: %w", err)
+	}
+
+	if output := ctx.String(dashboardOutputFlag); output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return d.WriteJSON(f)
+	}
+	return d.WriteJSON(os.Stdout)
+}
@@ -2,18 +2,26 @@ package main
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v2"
 
-	"github.com/sch8ill/mystprom/api/coingecko"
+	"github.com/sch8ill/mystprom/alerts"
 	"github.com/sch8ill/mystprom/api/mystnodes"
 	"github.com/sch8ill/mystprom/config"
+	"github.com/sch8ill/mystprom/digest"
+	"github.com/sch8ill/mystprom/geoip"
+	"github.com/sch8ill/mystprom/ledger"
 	"github.com/sch8ill/mystprom/metrics"
 	"github.com/sch8ill/mystprom/monitor"
+	"github.com/sch8ill/mystprom/prices"
+	"github.com/sch8ill/mystprom/push"
+	"github.com/sch8ill/mystprom/report"
 )
 
 func main() {
@@ -26,9 +34,11 @@ func main() {
 }
 
 func run(ctx *cli.Context) error {
-	config.SetConfig(ctx)
+	if err := config.SetConfig(ctx); err != nil {
+		return err
+	}
 	log.Info().Str("email", config.MystAPIEmail).Bool("password", config.MystAPIPassword != "").Msg("Credentials")
-	log.Info().Str("interval", config.ScrapeInterval.String()).Str("metrics_address", config.MetricsAddress).Str("refresh_file", config.RefreshFile).Msg("Config")
+	log.Info().Str("interval", config.ScrapeInterval.String()).Str("metrics_address", config.MetricsAddress).Str("refresh_file", config.RefreshFile).Strs("price_sources", config.PriceSources).Strs("price_currencies", config.PriceCurrencies).Str("ledger_file", config.LedgerFile).Str("timezone", config.Timezone.String()).Msg("Config")
 
 	credentials := mystnodes.Credentials{
 		Email:    config.MystAPIEmail,
@@ -45,12 +55,89 @@ func run(ctx *cli.Context) error {
 		return fmt.Errorf("failed to create MystAPI client: %w", err)
 	}
 
-	coingecko, err := coingecko.New()
+	sources, err := prices.NewSources(config.PriceSources, prices.Options{
+		Currencies:      config.PriceCurrencies,
+		CoingeckoAPIKey: config.CoingeckoAPIKey,
+		CoingeckoPro:    config.CoingeckoPro,
+	})
+	if err != nil {
+		return err
+	}
+
+	priceAggregator, err := prices.NewAggregator(prices.Strategy(config.PriceAggregation), sources...)
 	if err != nil {
-		return fmt.Errorf("failed to create CryptoCompare api client: %w", err)
+		return fmt.Errorf("failed to create price aggregator: %w", err)
+	}
+
+	var l *ledger.Ledger
+	if config.LedgerFile != "" {
+		l, err = ledger.Open(config.LedgerFile)
+		if err != nil {
+			return err
+		}
+		defer l.Close()
+
+		// the ledger is locked while the exporter runs, so reports are served from here
+		if config.ReportEndpoint {
+			http.Handle("/report/earnings", report.Handler(l, config.Timezone))
+		}
+	}
+
+	var geo *geoip.DB
+	if len(config.GeoIPDatabases) > 0 {
+		geo, err = geoip.Open(config.GeoIPDatabases...)
+		if err != nil {
+			return err
+		}
+		defer geo.Close()
+	}
+
+	var alerter *alerts.Alerter
+	if len(config.AlertWebhooks) > 0 {
+		alerter, err = newAlerter()
+		if err != nil {
+			return err
+		}
+	}
+
+	var collector *digest.Collector
+	if config.DigestSMTP != "" || config.DigestWebhook != "" || config.DigestDirectory != "" {
+		collector = digest.NewCollector(time.Now(), l)
+		scheduler, err := newDigestScheduler(collector)
+		if err != nil {
+			return err
+		}
+		scheduler.Start()
+		defer scheduler.Stop()
+	}
+
+	var pusher *push.Pusher
+	if config.PushGateway != "" || config.PushRemoteWrite != "" {
+		pusher, err = push.New(push.Options{
+			Pushgateway: config.PushGateway,
+			RemoteWrite: config.PushRemoteWrite,
+			Job:         config.PushJob,
+			Account:     config.PushAccount,
+			PushgatewayAuth: push.Auth{
+				Username:    config.PushGatewayUsername,
+				Password:    config.PushGatewayPassword,
+				BearerToken: config.PushGatewayBearerToken,
+			},
+			RemoteWriteAuth: push.Auth{
+				Username:    config.PushRemoteWriteUsername,
+				Password:    config.PushRemoteWritePassword,
+				BearerToken: config.PushRemoteWriteBearerToken,
+			},
+			QueueSize: config.PushQueueSize,
+		})
+		if err != nil {
+			return err
+		}
+		pusher.Start()
+		defer pusher.Stop()
 	}
 
-	m := monitor.New(mystApi, coingecko, config.ScrapeInterval)
+	m := monitor.New(mystApi, priceAggregator, l, geo, alerter, collector, pusher, config.ScrapeInterval)
 	m.Start()
 	defer m.Stop()
 
@@ -61,6 +148,56 @@ func run(ctx *cli.Context) error {
 	return nil
 }
 
+func newAlerter() (*alerts.Alerter, error) {
+	notifiers, err := alerts.NewWebhooks(config.AlertWebhooks)
+	if err != nil {
+		return nil, err
+	}
+
+	silences, err := alerts.ParseSilences(config.AlertSilences)
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts.New(notifiers, alerts.Options{
+		QualityThreshold: config.AlertQualityThreshold,
+		For:              config.AlertFor,
+		Silences:         silences,
+	}), nil
+}
+
+func newDigestScheduler(collector *digest.Collector) (*digest.Scheduler, error) {
+	renderer, err := digest.NewRenderer(config.DigestFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	var senders []digest.Sender
+	if config.DigestSMTP != "" {
+		mail, err := digest.NewMail(config.DigestSMTP, config.DigestMailFrom, config.DigestMailTo)
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, mail)
+	}
+	if config.DigestWebhook != "" {
+		webhook, err := digest.NewWebhook(config.DigestWebhook)
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, webhook)
+	}
+	if config.DigestDirectory != "" {
+		dir, err := digest.NewDirectory(config.DigestDirectory)
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, dir)
+	}
+
+	return digest.NewScheduler(collector, config.DigestSchedule, config.Timezone, renderer, senders...)
+}
+
 func createApp() *cli.App {
 	return &cli.App{
 		Name:      "mystprom",
@@ -68,6 +205,7 @@ func createApp() *cli.App {
 		Copyright: "Copyright (c) 2024 Sch8ill",
 		Action:    run,
 		Flags:     config.DeclareFlags(),
+		Commands:  []*cli.Command{reportCommand(), metricsCommand(), dashboardCommand(), rulesCommand(), alertsCommand()},
 	}
 }
 
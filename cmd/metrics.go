@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/sch8ill/mystprom/metrics"
+)
+
+const (
+	metricsFormatFlag    = "format"
+	metricsReadmeFlag    = "readme"
+	metricsDashboardFlag = "dashboard"
+
+	// markers enclosing the generated metrics table in the readme
+	metricsTableBegin = "<!-- metrics-table:begin -->"
+	metricsTableEnd   = "<!-- metrics-table:end -->"
+)
+
+func metricsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "metrics",
+		Usage: "Inspect the metrics exported by mystprom.",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "describe",
+				Usage:  "Print the catalog of exported metrics.",
+				Action: describeMetrics,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  metricsFormatFlag,
+						Usage: "output format (markdown, json)",
+						Value: "markdown",
+					},
+					&cli.StringFlag{
+						Name:  metricsReadmeFlag,
+						Usage: "replace the metrics table in the given readme instead of printing the markdown table",
+					},
+				},
+			},
+			{
+				Name:   "lint",
+				Usage:  "Check that a Grafana dashboard only references exported metrics.",
+				Action: lintDashboard,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     metricsDashboardFlag,
+						Usage:    "Grafana dashboard json file",
+						Required: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func describeMetrics(ctx *cli.Context) error {
+	catalog := metrics.Catalog()
+
+	switch format := ctx.String(metricsFormatFlag); format {
+	case "json":
+		return metrics.WriteJSON(os.Stdout, catalog)
+	case "markdown":
+		if readme := ctx.String(metricsReadmeFlag); readme != "" {
+			return updateReadme(readme, catalog)
+		}
+		return metrics.WriteMarkdown(os.Stdout, catalog)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// updateReadme replaces the metrics table between the table markers of the readme.
+func updateReadme(path string, catalog []metrics.Metric) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	content := string(data)
+	begin := strings.Index(content, metricsTableBegin)
+	end := strings.Index(content, metricsTableEnd)
+	if begin < 0 || end < begin {
+		return fmt.Errorf("%s does not contain the markers %s and %s", path, metricsTableBegin, metricsTableEnd)
+	}
+
+	var table bytes.Buffer
+	if err := metrics.WriteMarkdown(&table, catalog); err != nil {
+		return err
+	}
+
+	content = content[:begin+len(metricsTableBegin)] + "\n" + table.String() + content[end:]
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+func lintDashboard(ctx *cli.Context) error {
+	f, err := os.Open(ctx.String(metricsDashboardFlag))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	unknown, err := metrics.LintDashboard(f)
+	if err != nil {
+		return fmt.Errorf("failed to lint dashboard: %w", err)
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("dashboard references unknown metrics: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
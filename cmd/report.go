@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/sch8ill/mystprom/config"
+	"github.com/sch8ill/mystprom/ledger"
+	"github.com/sch8ill/mystprom/report"
+)
+
+const (
+	reportFromFlag     = "from"
+	reportToFlag       = "to"
+	reportCurrencyFlag = "currency"
+	reportFormatFlag   = "format"
+)
+
+func reportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "report",
+		Usage: "Generate reports from the earnings ledger.",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "earnings",
+				Usage:  "Report daily earnings per node and service and settlements, e.g. for accounting.",
+				Action: reportEarnings,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  reportFromFlag,
+						Usage: "first day of the report (YYYY-MM-DD), defaults to the start of the current year",
+					},
+					&cli.StringFlag{
+						Name:  reportToFlag,
+						Usage: "last day of the report (YYYY-MM-DD), defaults to today",
+					},
+					&cli.StringFlag{
+						Name:  reportCurrencyFlag,
+						Usage: "currency the earnings are valued in",
+						Value: report.MystCurrency,
+					},
+					&cli.StringFlag{
+						Name:  reportFormatFlag,
+						Usage: "output format (csv, json)",
+						Value: "csv",
+					},
+					&cli.StringFlag{
+						Name:     config.LedgerFileFlag,
+						Usage:    "name of the file the earnings ledger is stored in",
+						Required: true,
+						EnvVars:  []string{"MYSTPROM_LEDGER_FILE"},
+					},
+					&cli.StringFlag{
+						Name:    config.TimezoneFlag,
+						Usage:   "timezone days are calculated in",
+						Value:   config.DefaultTimezone,
+						EnvVars: []string{"MYSTPROM_TIMEZONE"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func reportEarnings(ctx *cli.Context) error {
+	loc, err := time.LoadLocation(ctx.String(config.TimezoneFlag))
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	from, to, err := report.ParseRange(ctx.String(reportFromFlag), ctx.String(reportToFlag), time.Now(), loc)
+	if err != nil {
+		return err
+	}
+
+	l, err := ledger.OpenReadOnly(ctx.String(config.LedgerFileFlag))
+	if errors.Is(err, ledger.ErrLocked) {
+		return fmt.Errorf("%w, query the /report/earnings endpoint of the running exporter with --%s instead",
+			err, config.ReportEndpointFlag)
+	}
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	r, err := report.EarningsReport(l, from, to, config.NormalizeCurrency(ctx.String(reportCurrencyFlag)), loc)
+	if err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+	if len(r.PriceFallbacks) > 0 {
+		fmt.Fprintf(os.Stderr, "no %s price recorded on %s, valued at the price of an earlier day\n",
+			r.Currency, strings.Join(r.PriceFallbacks, ", "))
+	}
+	if len(r.MissingPrices) > 0 {
+		fmt.Fprintf(os.Stderr, "no %s price recorded on or before %s, left without value\n",
+			r.Currency, strings.Join(r.MissingPrices, ", "))
+	}
+
+	switch format := ctx.String(reportFormatFlag); format {
+	case "csv":
+		return r.WriteCSV(os.Stdout)
+	case "json":
+		return r.WriteJSON(os.Stdout)
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
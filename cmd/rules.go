@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/sch8ill/mystprom/rules"
+)
+
+const (
+	rulesOfflineForFlag       = "offline-for"
+	rulesQualityThresholdFlag = "quality-threshold"
+	rulesQualityForFlag       = "quality-for"
+	rulesEarningsStalledFlag  = "earnings-stalled"
+	rulesExporterStaleFlag    = "exporter-stale"
+	rulesOutputFlag           = "output"
+)
+
+func rulesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "rules",
+		Usage: "Generate Prometheus rules for the exported metrics.",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "generate",
+				Usage:  "Generate a Prometheus rule file with alerting and recording rules.",
+				Action: generateRules,
+				Flags: []cli.Flag{
+					&cli.DurationFlag{
+						Name:  rulesOfflineForFlag,
+						Usage: "how long a node or service must be down before alerting",
+						Value: rules.DefaultOfflineFor,
+					},
+					&cli.Float64Flag{
+						Name:  rulesQualityThresholdFlag,
+						Usage: "quality below which a node is alerted",
+						Value: rules.DefaultQualityThreshold,
+					},
+					&cli.DurationFlag{
+						Name:  rulesQualityForFlag,
+						Usage: "how long the quality must be below the threshold before alerting",
+						Value: rules.DefaultQualityFor,
+					},
+					&cli.DurationFlag{
+						Name:  rulesEarningsStalledFlag,
+						Usage: "how long an online node may not earn before alerting",
+						Value: rules.DefaultEarningsStalled,
+					},
+					&cli.DurationFlag{
+						Name:  rulesExporterStaleFlag,
+						Usage: "how long mystprom may not monitor the nodes successfully before alerting",
+						Value: rules.DefaultExporterStale,
+					},
+					&cli.StringFlag{
+						Name:    rulesOutputFlag,
+						Aliases: []string{"o"},
+						Usage:   "file to write the rules to instead of stdout",
+					},
+				},
+			},
+		},
+	}
+}
+
+func generateRules(ctx *cli.Context) error {
+	f, err := rules.Generate(rules.Options{
+		OfflineFor:       ctx.Duration(rulesOfflineForFlag),
+		QualityThreshold: ctx.Float64(rulesQualityThresholdFlag),
+		QualityFor:       ctx.Duration(rulesQualityForFlag),
+		EarningsStalled:  ctx.Duration(rulesEarningsStalledFlag),
+		ExporterStale:    ctx.Duration(rulesExporterStaleFlag),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate rules: %w", err)
+	}
+
+	if output := ctx.String(rulesOutputFlag); output != "" {
+		out, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		return f.WriteYAML(out)
+	}
+	return f.WriteYAML(os.Stdout)
+}
@@ -0,0 +1,71 @@
+// Package countries provides ISO 3166-1 alpha-2 country codes with names and
+// continents from an embedded table.
+package countries
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"strings"
+)
+
+const (
+	Unknown = "unknown"
+	Other   = "other"
+)
+
+type Country struct {
+	Code      string
+	Name      string
+	Continent string
+}
+
+//go:embed countries.csv
+var table string
+
+var countries = parseTable(table)
+
+// aliases maps commonly used non ISO codes to their ISO code.
+var aliases = map[string]string{
+	"UK": "GB",
+	"EL": "GR",
+}
+
+// Normalize returns the upper case ISO code of a country code. Empty and
+// unknown codes are mapped to Unknown.
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if alias, ok := aliases[code]; ok {
+		code = alias
+	}
+
+	if _, ok := countries[code]; !ok {
+		return Unknown
+	}
+	return code
+}
+
+// Lookup returns the country of a normalized code. The name and continent of
+// Unknown and Other are empty.
+func Lookup(code string) Country {
+	if c, ok := countries[code]; ok {
+		return c
+	}
+	return Country{Code: code}
+}
+
+func parseTable(table string) map[string]Country {
+	records, err := csv.NewReader(strings.NewReader(table)).ReadAll()
+	if err != nil {
+		panic("invalid country table: " + err.Error())
+	}
+
+	parsed := make(map[string]Country, len(records))
+	for _, record := range records[1:] {
+		parsed[record[0]] = Country{
+			Code:      record[0],
+			Name:      record[1],
+			Continent: record[2],
+		}
+	}
+	return parsed
+}
@@ -0,0 +1,252 @@
+// Package dashboard generates Grafana dashboards from the metric catalog.
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/sch8ill/mystprom/metrics"
+)
+
+// Sections of the dashboard.
+const (
+	Nodes    = "nodes"
+	Earnings = "earnings"
+	Sessions = "sessions"
+	Rewards  = "rewards"
+	Global   = "global"
+)
+
+// AllSections lists every section in the order they appear in the dashboard.
+var AllSections = []string{Nodes, Earnings, Sessions, Rewards, Global}
+
+const (
+	panelWidth  = 12
+	panelHeight = 8
+	gridWidth   = 24
+
+	datasource = "${datasource}"
+)
+
+type Options struct {
+	Title string
+	// Sections are the sections included in the dashboard.
+	Sections []string
+	// Scheme is the metric scheme the exporter is configured with.
+	Scheme string
+	// AccountLabel and GroupLabel are the target labels the account and group
+	// variables filter by. They are set in the scrape config of Prometheus.
+	AccountLabel string
+	GroupLabel   string
+}
+
+type Dashboard struct {
+	Title         string     `json:"title"`
+	UID           string     `json:"uid"`
+	Tags          []string   `json:"tags"`
+	Timezone      string     `json:"timezone"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Refresh       string     `json:"refresh"`
+	Time          TimeRange  `json:"time"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+type Variable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label"`
+	Type       string      `json:"type"`
+	Query      interface{} `json:"query"`
+	Datasource string      `json:"datasource,omitempty"`
+	Refresh    int         `json:"refresh,omitempty"`
+	Multi      bool        `json:"multi"`
+	IncludeAll bool        `json:"includeAll"`
+	AllValue   string      `json:"allValue,omitempty"`
+	Sort       int         `json:"sort,omitempty"`
+}
+
+type Panel struct {
+	ID          int          `json:"id"`
+	Title       string       `json:"title"`
+	Type        string       `json:"type"`
+	Description string       `json:"description,omitempty"`
+	Datasource  string       `json:"datasource,omitempty"`
+	GridPos     GridPos      `json:"gridPos"`
+	Collapsed   bool         `json:"collapsed,omitempty"`
+	Targets     []Target     `json:"targets,omitempty"`
+	FieldConfig *FieldConfig `json:"fieldConfig,omitempty"`
+}
+
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Target struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+	Instant      bool   `json:"instant,omitempty"`
+	Format       string `json:"format,omitempty"`
+}
+
+type FieldConfig struct {
+	Defaults FieldDefaults `json:"defaults"`
+}
+
+type FieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+// grafanaUnits maps catalog units to Grafana units. Units are not interpolated,
+// so panels valued in the currency variable name it in the title instead.
+var grafanaUnits = map[string]string{
+	"bytes":            "bytes",
+	"bytes_per_second": "Bps",
+	"seconds":          "s",
+	"unixtime":         "dateTimeAsIso",
+	"gigabytes":        "decgbytes",
+	"mbit/s":           "Mbits",
+	"percent":          "percent",
+	"ratio":            "percentunit",
+	"MYST":             "suffix:MYST",
+}
+
+// Generate creates a dashboard with the panels of the included sections. The
+// metrics, units and descriptions of the panels are taken from the catalog.
+func Generate(opts Options) (*Dashboard, error) {
+	for _, section := range opts.Sections {
+		if !slices.Contains(AllSections, section) {
+			return nil, fmt.Errorf("unknown section: %s", section)
+		}
+	}
+
+	d := &Dashboard{
+		Title:         opts.Title,
+		UID:           "mystprom",
+		Tags:          []string{"mystprom", "mysterium"},
+		Timezone:      "browser",
+		SchemaVersion: 39,
+		Refresh:       "5m",
+		Time:          TimeRange{From: "now-7d", To: "now"},
+		Templating:    Templating{List: variables(opts)},
+	}
+
+	var y int
+	for _, section := range AllSections {
+		if !slices.Contains(opts.Sections, section) {
+			continue
+		}
+
+		d.addPanel(Panel{
+			Title:   strings.ToUpper(section[:1]) + section[1:],
+			Type:    "row",
+			GridPos: GridPos{H: 1, W: gridWidth, X: 0, Y: y},
+		})
+		y++
+
+		var x int
+		for _, spec := range panels {
+			if spec.section != section {
+				continue
+			}
+			panel, err := spec.panel(opts)
+			if err != nil {
+				return nil, err
+			}
+
+			if x+panel.GridPos.W > gridWidth {
+				x = 0
+				y += panelHeight
+			}
+			panel.GridPos.X, panel.GridPos.Y = x, y
+			x += panel.GridPos.W
+			d.addPanel(panel)
+		}
+		if x > 0 {
+			y += panelHeight
+		}
+	}
+
+	return d, nil
+}
+
+func (d *Dashboard) addPanel(panel Panel) {
+	panel.ID = len(d.Panels) + 1
+	d.Panels = append(d.Panels, panel)
+}
+
+// WriteJSON writes the dashboard as json, which can be imported into Grafana.
+func (d *Dashboard) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+func variables(opts Options) []Variable {
+	filter := fmt.Sprintf(`%s=~"$account", %s=~"$group"`, opts.AccountLabel, opts.GroupLabel)
+
+	return []Variable{
+		{
+			Name:  "datasource",
+			Label: "Data source",
+			Type:  "datasource",
+			Query: "prometheus",
+		},
+		labelVariable("account", "Account", fmt.Sprintf("label_values(myst_node_count, %s)", opts.AccountLabel), true),
+		labelVariable("group", "Group", fmt.Sprintf(`label_values(myst_node_count{%s=~"$account"}, %s)`,
+			opts.AccountLabel, opts.GroupLabel), true),
+		labelVariable("node", "Node", fmt.Sprintf("label_values(myst_node_online{%s}, name)", filter), true),
+		labelVariable("currency", "Currency", "label_values(myst_token_price, currency)", false),
+	}
+}
+
+func labelVariable(name string, label string, query string, multi bool) Variable {
+	v := Variable{
+		Name:       name,
+		Label:      label,
+		Type:       "query",
+		Query:      query,
+		Datasource: datasource,
+		// refresh on time range change
+		Refresh: 2,
+		Sort:    1,
+	}
+	if multi {
+		v.Multi = true
+		v.IncludeAll = true
+		// matches series without the label, e.g. if accounts are not labelled
+		v.AllValue = ".*"
+	}
+	return v
+}
+
+// selector returns the label matchers of the variables applying to the metric.
+func selector(m metrics.Metric, opts Options) string {
+	matchers := []string{
+		fmt.Sprintf(`%s=~"$account"`, opts.AccountLabel),
+		fmt.Sprintf(`%s=~"$group"`, opts.GroupLabel),
+	}
+	if slices.Contains(m.Labels, "name") {
+		matchers = append(matchers, `name=~"$node"`)
+	}
+	if slices.Contains(m.Labels, "currency") {
+		matchers = append(matchers, `currency="$currency"`)
+	}
+	return "{" + strings.Join(matchers, ", ") + "}"
+}
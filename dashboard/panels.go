@@ -0,0 +1,155 @@
+package dashboard
+
+import (
+	"fmt"
+
+	"github.com/sch8ill/mystprom/metrics"
+)
+
+// panelSpec describes a curated panel of the dashboard.
+type panelSpec struct {
+	section string
+	title   string
+	// kind is the Grafana panel type, timeseries by default.
+	kind string
+	// width is the width of the panel in grid units, panelWidth by default.
+	width int
+	// metrics are catalog metrics the panel can be built from. The first one
+	// exported with the scheme of the dashboard is used, e.g. to pick between
+	// the v1 and v2 name of a metric.
+	metrics []string
+	// query is formatted with the metric name and the selector of the dashboard
+	// variables.
+	query  string
+	legend string
+	// instant queries only the latest value, e.g. for tables and bar gauges.
+	instant bool
+}
+
+// panels lists the panels of the dashboard in the order they appear in their
+// section. Per node series are limited to small numbers per node, everything
+// else is aggregated.
+var panels = []panelSpec{
+	{section: Nodes, title: "Online nodes", kind: "stat", width: 6,
+		metrics: []string{"myst_node_online"}, query: "sum(%s%s)", legend: "online"},
+	{section: Nodes, title: "Time since last cycle", kind: "stat", width: 6,
+		metrics: []string{"myst_exporter_last_cycle_timestamp_seconds"}, query: "time() - %s%s", legend: "last cycle"},
+	{section: Nodes, title: "Online", kind: "state-timeline",
+		metrics: []string{"myst_node_online"}, query: "%s%s", legend: "{{name}}"},
+	{section: Nodes, title: "Monitoring status", kind: "table",
+		metrics: []string{"myst_node_monitoring_status", "myst_node_monitoring_status_state"}, query: "%s%s == 1", instant: true},
+	{section: Nodes, title: "IP category", kind: "table",
+		metrics: []string{"myst_node_ip_category", "myst_node_ip_category_state"}, query: "%s%s == 1", instant: true},
+	{section: Nodes, title: "Quality",
+		metrics: []string{"myst_node_quality"}, query: "%s%s", legend: "{{name}}"},
+	{section: Nodes, title: "Bandwidth",
+		metrics: []string{"myst_node_bandwidth"}, query: "%s%s", legend: "{{name}}"},
+	{section: Nodes, title: "Services", kind: "state-timeline",
+		metrics: []string{"myst_node_service"}, query: "%s%s", legend: "{{name}} {{service}}"},
+	{section: Nodes, title: "Traffic",
+		metrics: []string{"myst_node_traffic", "myst_node_traffic_bytes"}, query: "%s%s", legend: "{{name}}"},
+	{section: Nodes, title: "Versions", kind: "table", width: gridWidth,
+		metrics: []string{"myst_node_version", "myst_node_info"}, query: "%s%s", instant: true},
+
+	{section: Earnings, title: "Lifetime earnings",
+		metrics: []string{"myst_node_earnings_lifetime"}, query: "%s%s", legend: "{{name}}"},
+	{section: Earnings, title: "Unsettled earnings",
+		metrics: []string{"myst_node_earnings_unsettled"}, query: "%s%s", legend: "{{name}}"},
+	{section: Earnings, title: "Earnings by service",
+		metrics: []string{"myst_node_earnings"}, query: "sum by (service) (%s%s)", legend: "{{service}}"},
+	{section: Earnings, title: "Lifetime earnings in $currency",
+		metrics: []string{"myst_node_earnings_lifetime_fiat"}, query: "sum(%s%s)", legend: "total"},
+	{section: Earnings, title: "Earnings today",
+		metrics: []string{"myst_node_earnings_today"}, query: "%s%s", legend: "{{name}}"},
+	{section: Earnings, title: "Settled per day",
+		metrics: []string{"myst_node_settled_amount_total"}, query: "increase(%s%s[1d])", legend: "{{name}}"},
+
+	{section: Sessions, title: "Sessions by service",
+		metrics: []string{"myst_node_sessions"}, query: "sum by (service) (%s%s)", legend: "{{service}}"},
+	{section: Sessions, title: "Sessions by country",
+		metrics: []string{"myst_node_sessions"}, query: "sum by (country) (%s%s)", legend: "{{country}}"},
+	{section: Sessions, title: "Session traffic by service",
+		metrics: []string{"myst_node_session_traffic", "myst_node_sessions_traffic_bytes"},
+		query:   "sum by (service) (%s%s)", legend: "{{service}}"},
+	{section: Sessions, title: "Session earnings by service",
+		metrics: []string{"myst_node_session_earnings"}, query: "sum by (service) (%s%s)", legend: "{{service}}"},
+	{section: Sessions, title: "Observed sessions per hour",
+		metrics: []string{"myst_node_observed_sessions_total"},
+		query:   "sum by (service) (increase(%s%s[1h]))", legend: "{{service}}"},
+	{section: Sessions, title: "Median session duration",
+		metrics: []string{"myst_node_session_duration_seconds"},
+		query:   "histogram_quantile(0.5, sum by (le, service) (rate(%s_bucket%s[$__rate_interval])))", legend: "{{service}}"},
+	{section: Sessions, title: "Session activity by hour", kind: "bargauge",
+		metrics: []string{"myst_fleet_session_activity"}, query: "sum by (hour) (%s%s)", legend: "{{hour}}", instant: true},
+	{section: Sessions, title: "Peak concurrent sessions",
+		metrics: []string{"myst_node_peak_concurrent_sessions"}, query: "%s%s", legend: "{{name}} {{window}}"},
+
+	{section: Rewards, title: "Reward points",
+		metrics: []string{"myst_reward_points"}, query: "%s%s", legend: "points"},
+	{section: Rewards, title: "Rank",
+		metrics: []string{"myst_reward_rank"}, query: "%s%s", legend: "rank"},
+	{section: Rewards, title: "Share of reward points",
+		metrics: []string{"myst_reward_points_share"}, query: "%s%s", legend: "share"},
+	{section: Rewards, title: "Stake",
+		metrics: []string{"myst_reward_stake"}, query: "%s%s", legend: "stake"},
+	{section: Rewards, title: "Leaderboard", kind: "table", width: gridWidth,
+		metrics: []string{"myst_reward_leaderboard_points"}, query: "%s%s", instant: true},
+
+	{section: Global, title: "MYST price in $currency",
+		metrics: []string{"myst_token_price"}, query: "%s%s", legend: "{{source}}"},
+	{section: Global, title: "MYST market cap in $currency",
+		metrics: []string{"myst_token_market_cap"}, query: "%s%s", legend: "{{source}}"},
+	{section: Global, title: "Network nodes",
+		metrics: []string{"myst_global_nodes"}, query: "%s%s", legend: "nodes"},
+	{section: Global, title: "Network traffic",
+		metrics: []string{"myst_global_traffic", "myst_global_traffic_bytes"}, query: "%s%s", legend: "traffic"},
+}
+
+// panel builds the panel from the first metric of the spec exported with the
+// scheme of the dashboard.
+func (s panelSpec) panel(opts Options) (Panel, error) {
+	m, err := s.metric(opts.Scheme)
+	if err != nil {
+		return Panel{}, err
+	}
+
+	panel := Panel{
+		Title:       s.title,
+		Type:        s.kind,
+		Description: m.Help,
+		Datasource:  datasource,
+		GridPos:     GridPos{H: panelHeight, W: s.width},
+		Targets: []Target{{
+			RefID:        "A",
+			Expr:         fmt.Sprintf(s.query, m.Name, selector(m, opts)),
+			LegendFormat: s.legend,
+			Instant:      s.instant,
+		}},
+	}
+	if panel.Type == "" {
+		panel.Type = "timeseries"
+	}
+	if panel.GridPos.W == 0 {
+		panel.GridPos.W = panelWidth
+	}
+	if panel.Type == "table" {
+		panel.Targets[0].Format = "table"
+	}
+	if unit, ok := grafanaUnits[m.DisplayUnit]; ok {
+		panel.FieldConfig = &FieldConfig{Defaults: FieldDefaults{Unit: unit}}
+	}
+	return panel, nil
+}
+
+func (s panelSpec) metric(scheme string) (metrics.Metric, error) {
+	for _, name := range s.metrics {
+		m, ok := metrics.Lookup(name)
+		if !ok {
+			return metrics.Metric{}, fmt.Errorf("panel %q uses unknown metric %s", s.title, name)
+		}
+		if m.Scheme == "" || m.Scheme == scheme {
+			return m, nil
+		}
+	}
+	return metrics.Metric{}, fmt.Errorf("panel %q has no metric of scheme %s", s.title, scheme)
+}
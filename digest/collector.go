@@ -0,0 +1,317 @@
+// Package digest creates scheduled digests of the fleet from the state of the
+// monitor cycles and delivers them via mail, webhook or files.
+package digest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+	"github.com/sch8ill/mystprom/api/mystnodes/rewards"
+	"github.com/sch8ill/mystprom/countries"
+	"github.com/sch8ill/mystprom/ledger"
+)
+
+// Collector accumulates the state of the monitor cycles since the last digest.
+// The earnings and sessions are read from the ledger if there is one, so the
+// first digest after a restart covers the whole period. Otherwise they are kept
+// in memory and the first digest after a restart only covers the time since
+// the start.
+type Collector struct {
+	mu sync.Mutex
+
+	ledger *ledger.Ledger
+
+	since     time.Time
+	nodes     map[string]*nodeState
+	countries map[string]*CountrySummary
+	prices    map[string]float64
+
+	// rank is the current reward rank, rankSince the rank at the start of the
+	// period. 0 means not ranked or unknown.
+	rank      int
+	rankSince int
+}
+
+type nodeState struct {
+	name    string
+	version string
+	deleted bool
+	online  bool
+
+	cycles        int
+	offlineCycles int
+
+	// baseline holds the lifetime earnings at the start of the period, total
+	// the latest lifetime earnings.
+	baseline    float64
+	hasBaseline bool
+	total       float64
+	earnings    float64
+
+	sessions int
+	traffic  int64
+}
+
+// NewCollector creates a collector whose first period starts at now. The ledger
+// is optional.
+func NewCollector(now time.Time, l *ledger.Ledger) *Collector {
+	return &Collector{
+		ledger:    l,
+		since:     now,
+		nodes:     make(map[string]*nodeState),
+		countries: make(map[string]*CountrySummary),
+		prices:    make(map[string]float64),
+	}
+}
+
+// resume moves the start of the current period back to since, e.g. to the last
+// scheduled digest before a restart.
+func (c *Collector) resume(since time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if since.Before(c.since) {
+		c.since = since
+	}
+}
+
+// ObserveNodes records the online status and version of the nodes. Nodes that
+// are no longer reported by the api are dropped.
+func (c *Collector) ObserveNodes(nodes []node.Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	present := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		present[n.Identity] = true
+	}
+	for id := range c.nodes {
+		if !present[id] {
+			delete(c.nodes, id)
+		}
+	}
+
+	for _, n := range nodes {
+		s := c.node(n.Identity)
+		s.name = n.Name
+		s.version = n.Version
+		s.deleted = n.Deleted
+		s.online = n.NodeStatus.Online
+
+		s.cycles++
+		if !n.NodeStatus.Online {
+			s.offlineCycles++
+		}
+	}
+}
+
+// ObserveEarnings records the lifetime earnings of the node.
+func (c *Collector) ObserveEarnings(id string, earnings node.LifetimeEarnings) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.node(id)
+	if !s.hasBaseline {
+		s.baseline = earnings.Total
+		s.hasBaseline = true
+	}
+	s.total = earnings.Total
+	s.earnings = earnings.Total - s.baseline
+}
+
+// ObserveSessions records sessions of the node that were not observed before.
+// With a ledger, the sessions are read from the ledger instead.
+func (c *Collector) ObserveSessions(id string, sessions []node.Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ledger == nil {
+		c.addSessions(c.node(id), sessions)
+	}
+}
+
+func (c *Collector) addSessions(s *nodeState, sessions []node.Session) {
+	for _, session := range sessions {
+		s.sessions++
+		s.traffic += session.Transferred
+
+		country := countries.Normalize(session.ConsumerCountry)
+		summary, ok := c.countries[country]
+		if !ok {
+			summary = &CountrySummary{Country: country}
+			c.countries[country] = summary
+		}
+		summary.Sessions++
+		summary.Earnings += session.Earning
+		summary.Traffic += session.Transferred
+	}
+}
+
+// readLedger replaces the earnings and sessions of the nodes in the current
+// period with those recorded in the ledger. Nodes the ledger fails for keep
+// their observed earnings.
+func (c *Collector) readLedger(now time.Time) {
+	for id, s := range c.nodes {
+		if s.deleted {
+			continue
+		}
+
+		baseline, records, err := c.ledger.Records(id, c.since, now)
+		if err != nil {
+			log.Warn().Err(err).Str("id", id).Msg("failed to read earnings of the digest from the ledger")
+			continue
+		}
+		if len(records) > 0 {
+			base := records[0]
+			if baseline != nil {
+				base = *baseline
+			}
+			s.earnings = records[len(records)-1].Total - base.Total
+		} else {
+			s.earnings = 0
+		}
+
+		// sessions are selected by the time they were recorded, as the api
+		// returns some only after the end of the period they started in
+		sessions, err := c.ledger.RecordedSessions(id, c.since, now)
+		if err != nil {
+			log.Warn().Err(err).Str("id", id).Msg("failed to read sessions of the digest from the ledger")
+			continue
+		}
+		c.addSessions(s, sessions)
+	}
+}
+
+// ObservePrices records the latest MYST prices.
+func (c *Collector) ObservePrices(prices map[string]float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for currency, price := range prices {
+		c.prices[currency] = price
+	}
+}
+
+// ObserveRanks records the reward rank of the current user.
+func (c *Collector) ObserveRanks(ranks []rewards.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rank = 0
+	for i := range ranks {
+		if ranks[i].Current {
+			c.rank = i + 1
+			break
+		}
+	}
+	if c.rankSince == 0 {
+		c.rankSince = c.rank
+	}
+}
+
+// Digest creates a digest of the period since the last digest and starts a
+// new period.
+func (c *Collector) Digest(now time.Time) *Digest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d := &Digest{
+		From: c.since,
+		To:   now,
+		Rank: RankChange{From: c.rankSince, To: c.rank},
+	}
+	if c.ledger != nil {
+		c.readLedger(now)
+	}
+
+	versions := make(map[string]int)
+	for id, s := range c.nodes {
+		if s.deleted {
+			continue
+		}
+
+		d.Nodes = append(d.Nodes, NodeSummary{
+			ID:            id,
+			Name:          s.name,
+			Version:       s.version,
+			Online:        s.online,
+			Cycles:        s.cycles,
+			OfflineCycles: s.offlineCycles,
+			Earnings:      s.earnings,
+			Sessions:      s.sessions,
+			Traffic:       s.traffic,
+		})
+		d.Earnings += s.earnings
+		d.Sessions += s.sessions
+		d.Traffic += s.traffic
+		if s.version != "" {
+			versions[s.version]++
+		}
+	}
+	sort.Slice(d.Nodes, func(i, j int) bool {
+		return d.Nodes[i].Name < d.Nodes[j].Name
+	})
+
+	for version, nodes := range versions {
+		d.Versions = append(d.Versions, VersionCount{Version: version, Nodes: nodes})
+	}
+	sort.Slice(d.Versions, func(i, j int) bool {
+		if d.Versions[i].Nodes != d.Versions[j].Nodes {
+			return d.Versions[i].Nodes > d.Versions[j].Nodes
+		}
+		return d.Versions[i].Version > d.Versions[j].Version
+	})
+
+	for _, summary := range c.countries {
+		d.Countries = append(d.Countries, *summary)
+	}
+	sort.Slice(d.Countries, func(i, j int) bool {
+		if d.Countries[i].Sessions != d.Countries[j].Sessions {
+			return d.Countries[i].Sessions > d.Countries[j].Sessions
+		}
+		return d.Countries[i].Country < d.Countries[j].Country
+	})
+
+	for currency, price := range c.prices {
+		d.Fiat = append(d.Fiat, Value{Currency: currency, Amount: d.Earnings * price})
+	}
+	sort.Slice(d.Fiat, func(i, j int) bool {
+		return d.Fiat[i].Currency < d.Fiat[j].Currency
+	})
+
+	c.reset(now)
+	return d
+}
+
+// reset starts a new period at now, continuing from the current state.
+func (c *Collector) reset(now time.Time) {
+	c.since = now
+	c.countries = make(map[string]*CountrySummary)
+	c.rankSince = c.rank
+
+	for id, s := range c.nodes {
+		if s.deleted {
+			delete(c.nodes, id)
+			continue
+		}
+		s.cycles = 0
+		s.offlineCycles = 0
+		s.baseline = s.total
+		s.earnings = 0
+		s.sessions = 0
+		s.traffic = 0
+	}
+}
+
+func (c *Collector) node(id string) *nodeState {
+	s, ok := c.nodes[id]
+	if !ok {
+		s = &nodeState{}
+		c.nodes[id] = s
+	}
+	return s
+}
@@ -0,0 +1,129 @@
+package digest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+	"github.com/sch8ill/mystprom/ledger"
+)
+
+func TestCollectorLedger(t *testing.T) {
+	l := openLedger(t)
+
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := since.Add(time.Hour * 24)
+
+	// earnings and sessions recorded before a restart of the exporter
+	for _, r := range []struct {
+		at    time.Time
+		total float64
+	}{{since.Add(-time.Hour), 10}, {since.Add(time.Hour), 12}, {since.Add(time.Hour * 2), 15}} {
+		if err := l.Record("0x1", r.at, node.LifetimeEarnings{Total: r.total}); err != nil {
+			t.Fatalf("failed to record earnings: %v", err)
+		}
+	}
+	backlog := node.Session{ConsumerCountry: "FR", ServiceType: "wireguard", Earning: 1, Transferred: 1000, StartedAt: since.Add(-time.Hour * 3)}
+	sessions := []node.Session{
+		{ConsumerCountry: "DE", ServiceType: "wireguard", Earning: 0.1, Transferred: 100, StartedAt: since.Add(-time.Hour)},
+		{ConsumerCountry: "DE", ServiceType: "wireguard", Earning: 0.2, Transferred: 200, StartedAt: since.Add(time.Hour)},
+		{ConsumerCountry: "US", ServiceType: "scraping", Earning: 0.3, Transferred: 300, StartedAt: since.Add(time.Hour * 2)},
+	}
+	recordSessions(t, l, since.Add(-time.Hour*2), backlog)
+	recordSessions(t, l, since.Add(-time.Minute*30), sessions[0])
+	recordSessions(t, l, since.Add(time.Hour), sessions[1])
+	recordSessions(t, l, since.Add(time.Hour*2), sessions[2])
+
+	c := NewCollector(now.Add(-time.Hour), l)
+	c.resume(since)
+	c.ObserveNodes([]node.Node{{Identity: "0x1", Name: "node-1", NodeStatus: node.Status{Online: true}}})
+	c.ObserveEarnings("0x1", node.LifetimeEarnings{Total: 15})
+	c.ObserveSessions("0x1", sessions[2:])
+
+	d := c.Digest(now)
+	if !d.From.Equal(since) {
+		t.Errorf("got period start %s, want %s", d.From, since)
+	}
+	if d.Earnings != 5 {
+		t.Errorf("got earnings %g, want 5", d.Earnings)
+	}
+	if d.Sessions != 2 || d.Traffic != 500 {
+		t.Errorf("got %d sessions and %d bytes, want 2 sessions and 500 bytes", d.Sessions, d.Traffic)
+	}
+	if len(d.Countries) != 2 || d.Countries[0].Country != "DE" || d.Countries[0].Sessions != 1 {
+		t.Errorf("got countries %+v, want DE and US with a session each", d.Countries)
+	}
+}
+
+func TestCollectorLateSession(t *testing.T) {
+	l := openLedger(t)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	boundary := start.Add(time.Hour * 24)
+	recordSessions(t, l, start)
+
+	c := NewCollector(start, l)
+	c.ObserveNodes([]node.Node{{Identity: "0x1", Name: "node-1"}})
+
+	// the session starts before the boundary, but is only returned by the api after it
+	late := node.Session{ConsumerCountry: "DE", ServiceType: "wireguard", Earning: 0.1, Transferred: 100,
+		StartedAt: boundary.Add(-time.Minute)}
+	recordSessions(t, l, boundary.Add(time.Minute*5), late)
+
+	if d := c.Digest(boundary); d.Sessions != 0 {
+		t.Errorf("got %d sessions before the session was recorded, want 0", d.Sessions)
+	}
+	if d := c.Digest(boundary.Add(time.Hour * 24)); d.Sessions != 1 || d.Traffic != 100 {
+		t.Errorf("got %d sessions and %d bytes in the next period, want the late session", d.Sessions, d.Traffic)
+	}
+}
+
+func openLedger(t *testing.T) *ledger.Ledger {
+	t.Helper()
+	l, err := ledger.Open(filepath.Join(t.TempDir(), "ledger.db"))
+	if err != nil {
+		t.Fatalf("failed to open ledger: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func recordSessions(t *testing.T, l *ledger.Ledger, at time.Time, sessions ...node.Session) {
+	t.Helper()
+	if _, err := l.RecordSessions("0x1", at, sessions); err != nil {
+		t.Fatalf("failed to record sessions: %v", err)
+	}
+}
+
+func TestCollectorDropsMissingNodes(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCollector(now, nil)
+
+	c.ObserveNodes([]node.Node{{Identity: "0x1", Name: "node-1"}, {Identity: "0x2", Name: "node-2"}})
+	c.ObserveEarnings("0x2", node.LifetimeEarnings{Total: 1})
+	c.ObserveNodes([]node.Node{{Identity: "0x1", Name: "node-1"}})
+
+	d := c.Digest(now.Add(time.Hour))
+	if len(d.Nodes) != 1 || d.Nodes[0].ID != "0x1" || d.Nodes[0].Cycles != 2 {
+		t.Errorf("got nodes %+v, want only node-1 with 2 cycles", d.Nodes)
+	}
+}
+
+func TestCollectorEarnings(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCollector(now, nil)
+
+	c.ObserveNodes([]node.Node{{Identity: "0x1", Name: "node-1"}})
+	c.ObserveEarnings("0x1", node.LifetimeEarnings{Total: 10})
+	c.ObserveEarnings("0x1", node.LifetimeEarnings{Total: 12})
+	if d := c.Digest(now.Add(time.Hour)); d.Earnings != 2 {
+		t.Errorf("got earnings %g, want 2", d.Earnings)
+	}
+
+	// the next period continues from the last observed earnings
+	c.ObserveEarnings("0x1", node.LifetimeEarnings{Total: 13})
+	if d := c.Digest(now.Add(time.Hour * 2)); d.Earnings != 1 {
+		t.Errorf("got earnings %g, want 1", d.Earnings)
+	}
+}
@@ -0,0 +1,117 @@
+package digest
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpSink is an in-process SMTP server that accepts every mail.
+type smtpSink struct {
+	listener net.Listener
+	mails    chan sinkMail
+}
+
+type sinkMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &smtpSink{listener: listener, mails: make(chan sinkMail, 1)}
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if err := s.serve(textproto.NewConn(conn)); err != nil {
+			t.Errorf("smtp sink: %v", err)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) serve(conn *textproto.Conn) error {
+	var mail sinkMail
+	if err := conn.PrintfLine("220 localhost ESMTP sink"); err != nil {
+		return err
+	}
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return err
+		}
+
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			err = conn.PrintfLine("250 localhost")
+		case "MAIL":
+			mail.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			err = conn.PrintfLine("250 OK")
+		case "RCPT":
+			mail.to = append(mail.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			err = conn.PrintfLine("250 OK")
+		case "DATA":
+			if err := conn.PrintfLine("354 send the mail"); err != nil {
+				return err
+			}
+			var lines []string
+			if lines, err = conn.ReadDotLines(); err != nil {
+				return err
+			}
+			mail.data = strings.Join(lines, "\n")
+			s.mails <- mail
+			err = conn.PrintfLine("250 OK")
+		case "QUIT":
+			return conn.PrintfLine("221 bye")
+		default:
+			err = conn.PrintfLine("502 not implemented")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func TestMail(t *testing.T) {
+	sink := newSMTPSink(t)
+	m, err := NewMail("smtp://"+sink.listener.Addr().String(), "mystprom@example.com",
+		[]string{"a@example.com", "b@example.com"})
+	if err != nil {
+		t.Fatalf("failed to create mail sender: %v", err)
+	}
+
+	r, err := NewRenderer(Text)
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	if err := m.Send(testDigest(), []byte("first line\nsecond line\n"), r); err != nil {
+		t.Fatalf("failed to send mail: %v", err)
+	}
+
+	mail := <-sink.mails
+	if mail.from != "mystprom@example.com" || strings.Join(mail.to, ",") != "a@example.com,b@example.com" {
+		t.Errorf("got mail from %s to %v", mail.from, mail.to)
+	}
+	for _, want := range []string{
+		"From: mystprom@example.com\n",
+		"To: a@example.com, b@example.com\n",
+		"Subject: mystprom digest 2026-01-01 - 2026-01-08\n",
+		"Content-Type: text/plain; charset=utf-8\n",
+		"\n\nfirst line\nsecond line",
+	} {
+		if !strings.Contains(mail.data, want) {
+			t.Errorf("mail does not contain %q:\n%s", want, mail.data)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package digest
+
+import (
+	"fmt"
+	"time"
+)
+
+// Digest summarizes the fleet over a period.
+type Digest struct {
+	From time.Time
+	To   time.Time
+
+	// Earnings is the increase of the lifetime earnings of all nodes in MYST.
+	Earnings float64
+	// Fiat are the earnings valued at the latest MYST prices.
+	Fiat     []Value
+	Sessions int
+	// Traffic is the traffic of the sessions in bytes.
+	Traffic int64
+
+	Nodes     []NodeSummary
+	Countries []CountrySummary
+	Versions  []VersionCount
+	Rank      RankChange
+}
+
+type Value struct {
+	Currency string
+	Amount   float64
+}
+
+type NodeSummary struct {
+	ID      string
+	Name    string
+	Version string
+	// Online is the status of the node in the last cycle.
+	Online bool
+	// Cycles is the number of monitor cycles the node was seen in, OfflineCycles
+	// the number of them it was offline in.
+	Cycles        int
+	OfflineCycles int
+	Earnings      float64
+	Sessions      int
+	Traffic       int64
+}
+
+type CountrySummary struct {
+	Country  string
+	Sessions int
+	Earnings float64
+	Traffic  int64
+}
+
+type VersionCount struct {
+	Version string
+	Nodes   int
+}
+
+// RankChange is the reward rank at the start and end of the period, 0 if not ranked.
+type RankChange struct {
+	From int
+	To   int
+}
+
+// Offline returns the nodes that were offline in at least one cycle.
+func (d *Digest) Offline() []NodeSummary {
+	var offline []NodeSummary
+	for _, n := range d.Nodes {
+		if n.OfflineCycles > 0 {
+			offline = append(offline, n)
+		}
+	}
+	return offline
+}
+
+// Subject is the title of the digest.
+func (d *Digest) Subject() string {
+	return fmt.Sprintf("mystprom digest %s - %s", d.From.Format(time.DateOnly), d.To.Format(time.DateOnly))
+}
+
+func (r RankChange) String() string {
+	switch {
+	case r.To == 0:
+		return "not ranked"
+	case r.From == 0 || r.From == r.To:
+		return fmt.Sprintf("#%d", r.To)
+	case r.To < r.From:
+		return fmt.Sprintf("#%d (up %d from #%d)", r.To, r.From-r.To, r.From)
+	default:
+		return fmt.Sprintf("#%d (down %d from #%d)", r.To, r.To-r.From, r.From)
+	}
+}
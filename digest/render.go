@@ -0,0 +1,219 @@
+package digest
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Renderer renders a digest in a format.
+type Renderer interface {
+	Render(w io.Writer, d *Digest) error
+	// ContentType is the mime type of the rendered digest.
+	ContentType() string
+	// Extension is the file extension of the rendered digest.
+	Extension() string
+}
+
+// Formats of the renderers.
+const (
+	Markdown = "markdown"
+	HTML     = "html"
+	Text     = "text"
+)
+
+// NewRenderer returns the renderer of the format.
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case Markdown:
+		return &templateRenderer{template: markdownTemplate, contentType: "text/markdown; charset=utf-8", extension: "md"}, nil
+	case HTML:
+		return &htmlRenderer{}, nil
+	case Text:
+		return &templateRenderer{template: textTemplate, contentType: "text/plain; charset=utf-8", extension: "txt"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported digest format: %s", format)
+	}
+}
+
+var funcs = map[string]any{
+	"myst":  func(v float64) string { return fmt.Sprintf("%.4f", v) },
+	"fiat":  func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"bytes": formatBytes,
+	"date":  func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+}
+
+type templateRenderer struct {
+	template    *template.Template
+	contentType string
+	extension   string
+}
+
+func (r *templateRenderer) Render(w io.Writer, d *Digest) error {
+	return r.template.Execute(w, d)
+}
+
+func (r *templateRenderer) ContentType() string {
+	return r.contentType
+}
+
+func (r *templateRenderer) Extension() string {
+	return r.extension
+}
+
+type htmlRenderer struct{}
+
+func (r *htmlRenderer) Render(w io.Writer, d *Digest) error {
+	return htmlTemplate.Execute(w, d)
+}
+
+func (r *htmlRenderer) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+func (r *htmlRenderer) Extension() string {
+	return "html"
+}
+
+// formatBytes formats b with a decimal unit, e.g. 1.50 GB.
+func formatBytes(b int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB", "PB"}
+	v, unit := float64(b), 0
+	for v >= 1000 && unit < len(units)-1 {
+		v /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", b)
+	}
+	return fmt.Sprintf("%.2f %s", v, units[unit])
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(funcs).Parse(strings.TrimSpace(`
+# {{ .Subject }}
+
+{{ date .From }} - {{ date .To }}
+
+## Earnings
+
+- **{{ myst .Earnings }} MYST**{{ range .Fiat }}
+- {{ fiat .Amount }} {{ .Currency }}{{ end }}
+- {{ .Sessions }} sessions, {{ bytes .Traffic }} traffic
+- Reward rank: {{ .Rank }}
+
+| node | version | earnings (MYST) | sessions | traffic | offline |
+|------|---------|-----------------|----------|---------|---------|
+{{- range .Nodes }}
+| {{ .Name }} | {{ .Version }} | {{ myst .Earnings }} | {{ .Sessions }} | {{ bytes .Traffic }} | {{ .OfflineCycles }}/{{ .Cycles }} |
+{{- end }}
+{{ with .Offline }}
+## Offline nodes
+{{ range . }}
+- {{ .Name }}: offline in {{ .OfflineCycles }} of {{ .Cycles }} checks{{ if not .Online }}, still offline{{ end }}
+{{- end }}
+{{ end }}
+## Sessions by country
+{{ if .Countries }}
+| country | sessions | earnings (MYST) | traffic |
+|---------|----------|-----------------|---------|
+{{- range .Countries }}
+| {{ .Country }} | {{ .Sessions }} | {{ myst .Earnings }} | {{ bytes .Traffic }} |
+{{- end }}
+{{ else }}
+No sessions.
+{{ end }}
+## Versions
+{{ range .Versions }}
+- {{ .Version }}: {{ .Nodes }} nodes
+{{- end }}
+`) + "\n"))
+
+var textTemplate = template.Must(template.New("text").Funcs(funcs).Parse(strings.TrimSpace(`
+{{ .Subject }}
+{{ date .From }} - {{ date .To }}
+
+EARNINGS
+  {{ myst .Earnings }} MYST{{ range .Fiat }}
+  {{ fiat .Amount }} {{ .Currency }}{{ end }}
+  {{ .Sessions }} sessions, {{ bytes .Traffic }} traffic
+  Reward rank: {{ .Rank }}
+
+NODES
+{{- range .Nodes }}
+  {{ .Name }} ({{ .Version }}): {{ myst .Earnings }} MYST, {{ .Sessions }} sessions, {{ bytes .Traffic }}, offline {{ .OfflineCycles }}/{{ .Cycles }}
+{{- end }}
+{{ with .Offline }}
+OFFLINE NODES
+{{- range . }}
+  {{ .Name }}: offline in {{ .OfflineCycles }} of {{ .Cycles }} checks{{ if not .Online }}, still offline{{ end }}
+{{- end }}
+{{ end }}
+SESSIONS BY COUNTRY
+{{- range .Countries }}
+  {{ .Country }}: {{ .Sessions }} sessions, {{ myst .Earnings }} MYST, {{ bytes .Traffic }}
+{{- else }}
+  No sessions.
+{{- end }}
+
+VERSIONS
+{{- range .Versions }}
+  {{ .Version }}: {{ .Nodes }} nodes
+{{- end }}
+`) + "\n"))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(strings.TrimSpace(`
+<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{ .Subject }}</title></head>
+<body>
+<h1>{{ .Subject }}</h1>
+<p>{{ date .From }} - {{ date .To }}</p>
+
+<h2>Earnings</h2>
+<ul>
+<li><b>{{ myst .Earnings }} MYST</b></li>
+{{- range .Fiat }}
+<li>{{ fiat .Amount }} {{ .Currency }}</li>
+{{- end }}
+<li>{{ .Sessions }} sessions, {{ bytes .Traffic }} traffic</li>
+<li>Reward rank: {{ .Rank }}</li>
+</ul>
+
+<table>
+<tr><th>node</th><th>version</th><th>earnings (MYST)</th><th>sessions</th><th>traffic</th><th>offline</th></tr>
+{{- range .Nodes }}
+<tr><td>{{ .Name }}</td><td>{{ .Version }}</td><td>{{ myst .Earnings }}</td><td>{{ .Sessions }}</td><td>{{ bytes .Traffic }}</td><td>{{ .OfflineCycles }}/{{ .Cycles }}</td></tr>
+{{- end }}
+</table>
+{{ with .Offline }}
+<h2>Offline nodes</h2>
+<ul>
+{{- range . }}
+<li>{{ .Name }}: offline in {{ .OfflineCycles }} of {{ .Cycles }} checks{{ if not .Online }}, still offline{{ end }}</li>
+{{- end }}
+</ul>
+{{ end }}
+<h2>Sessions by country</h2>
+{{- if .Countries }}
+<table>
+<tr><th>country</th><th>sessions</th><th>earnings (MYST)</th><th>traffic</th></tr>
+{{- range .Countries }}
+<tr><td>{{ .Country }}</td><td>{{ .Sessions }}</td><td>{{ myst .Earnings }}</td><td>{{ bytes .Traffic }}</td></tr>
+{{- end }}
+</table>
+{{- else }}
+<p>No sessions.</p>
+{{- end }}
+
+<h2>Versions</h2>
+<ul>
+{{- range .Versions }}
+<li>{{ .Version }}: {{ .Nodes }} nodes</li>
+{{- end }}
+</ul>
+</body>
+</html>
+`) + "\n"))
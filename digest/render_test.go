@@ -0,0 +1,66 @@
+package digest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func testDigest() *Digest {
+	return &Digest{
+		From:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC),
+		Earnings: 1.23456,
+		Fiat:     []Value{{Currency: "EUR", Amount: 0.25}, {Currency: "USD", Amount: 0.3}},
+		Sessions: 3,
+		Traffic:  1_500_000_000,
+		Nodes: []NodeSummary{
+			{ID: "0x1", Name: "node-1", Version: "1.30.0", Online: true, Cycles: 1008, Earnings: 1.0,
+				Sessions: 2, Traffic: 1_000_000_000},
+			{ID: "0x2", Name: "node-<2>", Version: "1.29.0", Online: false, Cycles: 1008, OfflineCycles: 12,
+				Earnings: 0.23456, Sessions: 1, Traffic: 500_000_000},
+		},
+		Countries: []CountrySummary{
+			{Country: "DE", Sessions: 2, Earnings: 0.2, Traffic: 1_000_000_000},
+			{Country: "US", Sessions: 1, Earnings: 0.1, Traffic: 500_000_000},
+		},
+		Versions: []VersionCount{{Version: "1.30.0", Nodes: 1}, {Version: "1.29.0", Nodes: 1}},
+		Rank:     RankChange{From: 12, To: 10},
+	}
+}
+
+func TestRenderers(t *testing.T) {
+	for _, format := range []string{Markdown, HTML, Text} {
+		t.Run(format, func(t *testing.T) {
+			r, err := NewRenderer(format)
+			if err != nil {
+				t.Fatalf("failed to create renderer: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := r.Render(&buf, testDigest()); err != nil {
+				t.Fatalf("failed to render digest: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "digest."+r.Extension())
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("rendered digest does not match %s:\n%s", golden, buf.String())
+			}
+		})
+	}
+}
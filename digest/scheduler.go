@@ -0,0 +1,94 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+// Scheduler sends digests of the collector on a cron schedule.
+type Scheduler struct {
+	collector *Collector
+	renderer  Renderer
+	senders   []Sender
+	cron      *cron.Cron
+}
+
+// NewScheduler creates a scheduler. The schedule is a standard cron expression
+// or descriptor like @daily or @weekly, evaluated in loc.
+func NewScheduler(collector *Collector, schedule string, loc *time.Location, renderer Renderer, senders ...Sender) (*Scheduler, error) {
+	s := &Scheduler{
+		collector: collector,
+		renderer:  renderer,
+		senders:   senders,
+		cron:      cron.New(cron.WithLocation(loc)),
+	}
+
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest schedule: %w", err)
+	}
+	s.cron.Schedule(sched, cron.FuncJob(s.send))
+
+	// the ledger holds the earnings and sessions since the last digest before
+	// the start, so the period continues from there
+	if collector.ledger != nil {
+		if prev, ok := previous(sched, time.Now().In(loc)); ok {
+			collector.resume(prev)
+		}
+	}
+	return s, nil
+}
+
+// lookback holds the growing windows previous searches for the last activation,
+// so frequent schedules only step through a few activations.
+var lookback = []time.Duration{time.Hour, time.Hour * 24, time.Hour * 24 * 7, time.Hour * 24 * 32, time.Hour * 24 * 366}
+
+// previous returns the last activation of the schedule within a year before now.
+// Intervals like @every 1h have none, as they start with the scheduler.
+func previous(sched cron.Schedule, now time.Time) (time.Time, bool) {
+	if _, ok := sched.(cron.ConstantDelaySchedule); ok {
+		return time.Time{}, false
+	}
+
+	for _, window := range lookback {
+		var prev time.Time
+		for t := sched.Next(now.Add(-window)); !t.IsZero() && !t.After(now); t = sched.Next(t) {
+			prev = t
+		}
+		if !prev.IsZero() {
+			return prev, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (s *Scheduler) Start() {
+	log.Info().Msg("Starting digest scheduler...")
+	s.cron.Start()
+}
+
+// Stop stops the scheduler and waits for a running digest to be sent.
+func (s *Scheduler) Stop() {
+	<-s.cron.Stop().Done()
+}
+
+func (s *Scheduler) send() {
+	d := s.collector.Digest(time.Now())
+
+	var body bytes.Buffer
+	if err := s.renderer.Render(&body, d); err != nil {
+		log.Warn().Err(err).Msg("failed to render digest")
+		return
+	}
+
+	for _, sender := range s.senders {
+		if err := sender.Send(d, body.Bytes(), s.renderer); err != nil {
+			log.Warn().Err(err).Msg("failed to send digest")
+		}
+	}
+	log.Info().Time("from", d.From).Time("to", d.To).Msg("Digest sent")
+}
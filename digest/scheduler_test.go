@@ -0,0 +1,35 @@
+package digest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestPrevious(t *testing.T) {
+	now := time.Date(2026, 3, 18, 10, 30, 30, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		want     time.Time
+	}{
+		{schedule: "* * * * *", want: time.Date(2026, 3, 18, 10, 30, 0, 0, time.UTC)},
+		{schedule: "@daily", want: time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 8 * * 1", want: time.Date(2026, 3, 16, 8, 0, 0, 0, time.UTC)},
+		{schedule: "@monthly", want: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{schedule: "@yearly", want: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{schedule: "@every 1h"},
+	}
+	for _, test := range tests {
+		t.Run(test.schedule, func(t *testing.T) {
+			sched, err := cron.ParseStandard(test.schedule)
+			if err != nil {
+				t.Fatalf("failed to parse schedule: %v", err)
+			}
+			got, ok := previous(sched, now)
+			if ok != !test.want.IsZero() || !got.Equal(test.want) {
+				t.Errorf("got previous activation %s, want %s", got, test.want)
+			}
+		})
+	}
+}
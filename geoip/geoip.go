@@ -0,0 +1,130 @@
+// Package geoip looks up the autonomous system and location of ip addresses in
+// local MaxMind mmdb databases.
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Info is the autonomous system and location of an ip address. Fields are
+// empty if the address is not found or no matching database is loaded.
+type Info struct {
+	ASN       uint
+	ASOrg     string
+	City      string
+	Country   string
+	Latitude  float64
+	Longitude float64
+	// HasLocation is set if coordinates are known.
+	HasLocation bool
+}
+
+type asnRecord struct {
+	Number uint   `maxminddb:"autonomous_system_number"`
+	Org    string `maxminddb:"autonomous_system_organization"`
+}
+
+type cityRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+// DB combines an ASN and a City database, either of which may be missing.
+type DB struct {
+	asn  *maxminddb.Reader
+	city *maxminddb.Reader
+}
+
+// Open opens the given mmdb files. The database type of each file is detected
+// from its metadata, so ASN and City databases may be passed in any order, but
+// at most one of each.
+func Open(paths ...string) (*DB, error) {
+	db := &DB{}
+	for _, path := range paths {
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to open geoip database %s: %w", path, err)
+		}
+
+		dbType := reader.Metadata.DatabaseType
+		var slot **maxminddb.Reader
+		switch {
+		case strings.HasSuffix(dbType, "-ASN"):
+			slot = &db.asn
+		case strings.HasSuffix(dbType, "-City"):
+			slot = &db.city
+		default:
+			reader.Close()
+			db.Close()
+			return nil, fmt.Errorf("unsupported geoip database type %q: %s", dbType, path)
+		}
+
+		if *slot != nil {
+			reader.Close()
+			db.Close()
+			return nil, fmt.Errorf("more than one geoip database of type %q: %s", dbType, path)
+		}
+		*slot = reader
+	}
+
+	return db, nil
+}
+
+func (db *DB) Close() error {
+	for _, reader := range []*maxminddb.Reader{db.asn, db.city} {
+		if reader != nil {
+			if err := reader.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup returns the autonomous system and location of the ip address.
+func (db *DB) Lookup(address string) (Info, error) {
+	var info Info
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return info, fmt.Errorf("invalid ip address: %q", address)
+	}
+
+	if db.asn != nil {
+		var record asnRecord
+		if err := db.asn.Lookup(ip, &record); err != nil {
+			return info, fmt.Errorf("failed to look up asn of %s: %w", address, err)
+		}
+		info.ASN = record.Number
+		info.ASOrg = record.Org
+	}
+
+	if db.city != nil {
+		var record cityRecord
+		if err := db.city.Lookup(ip, &record); err != nil {
+			return info, fmt.Errorf("failed to look up city of %s: %w", address, err)
+		}
+		info.City = record.City.Names["en"]
+		info.Country = record.Country.ISOCode
+		if record.Location.Latitude != nil && record.Location.Longitude != nil {
+			info.Latitude = *record.Location.Latitude
+			info.Longitude = *record.Location.Longitude
+			info.HasLocation = true
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,329 @@
+// Package ledger provides a persistent on-disk ledger of node earnings.
+package ledger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	berrors "go.etcd.io/bbolt/errors"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+)
+
+var earningsBucket = []byte("earnings")
+
+// ErrLocked is returned by OpenReadOnly if the ledger is open for writing.
+var ErrLocked = errors.New("ledger is locked by another process")
+
+type Ledger struct {
+	db *bolt.DB
+}
+
+// Record holds the lifetime earnings of a node at a point in time.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Total     float64   `json:"total"`
+	Settled   float64   `json:"settled"`
+	Unsettled float64   `json:"unsettled"`
+}
+
+// Day holds the earnings of a node over a single day.
+type Day struct {
+	Date     time.Time
+	Earnings float64
+	// Last is the last record of the day.
+	Last Record
+}
+
+func Open(path string) (*Ledger, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{earningsBucket, pricesBucket, sessionsBucket, recordedBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize ledger: %w", err)
+	}
+
+	return &Ledger{db: db}, nil
+}
+
+// OpenReadOnly opens the ledger at path without modifying it. Multiple read only
+// ledgers can be open at the same time, but not while the ledger is open for
+// writing, e.g. by a running exporter.
+func OpenReadOnly(path string) (*Ledger, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if errors.Is(err, berrors.ErrTimeout) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+
+	if err := db.View(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{earningsBucket, pricesBucket, sessionsBucket} {
+			if tx.Bucket(bucket) == nil {
+				return fmt.Errorf("missing bucket %s", bucket)
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("invalid ledger: %w", err)
+	}
+
+	return &Ledger{db: db}, nil
+}
+
+func (l *Ledger) Close() error {
+	return l.db.Close()
+}
+
+// Record stores the lifetime earnings of the node with the given identity.
+func (l *Ledger) Record(id string, t time.Time, earnings node.LifetimeEarnings) error {
+	record := Record{
+		Time:      t.UTC(),
+		Total:     earnings.Total,
+		Settled:   earnings.Settled,
+		Unsettled: earnings.Unsettled,
+	}
+
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return l.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(earningsBucket).CreateBucketIfNotExists([]byte(id))
+		if err != nil {
+			return err
+		}
+		return b.Put(timeKey(t), value)
+	})
+}
+
+// Nodes returns the identities of all nodes with recorded earnings.
+func (l *Ledger) Nodes() ([]string, error) {
+	var ids []string
+	err := l.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(earningsBucket).ForEachBucket(func(k []byte) error {
+			ids = append(ids, string(k))
+			return nil
+		})
+	})
+	return ids, err
+}
+
+// Last returns the last record of the node, or nil if there is none.
+func (l *Ledger) Last(id string) (*Record, error) {
+	var record *Record
+	err := l.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(earningsBucket).Bucket([]byte(id))
+		if b == nil {
+			return nil
+		}
+
+		_, v := b.Cursor().Last()
+		if v == nil {
+			return nil
+		}
+		record = new(Record)
+		return json.Unmarshal(v, record)
+	})
+	return record, err
+}
+
+// Records returns the records of the node in [from, to). The last record before
+// from is returned separately as baseline and is nil if there is none.
+func (l *Ledger) Records(id string, from, to time.Time) (baseline *Record, records []Record, err error) {
+	err = l.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(earningsBucket).Bucket([]byte(id))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		k, v := c.Seek(timeKey(from))
+
+		// step back to find the baseline before the range
+		var pk, pv []byte
+		if k == nil {
+			pk, pv = c.Last()
+		} else {
+			pk, pv = c.Prev()
+			c.Seek(k)
+		}
+		if pk != nil {
+			baseline = new(Record)
+			if err := json.Unmarshal(pv, baseline); err != nil {
+				return err
+			}
+		}
+
+		end := timeKey(to)
+		for ; k != nil && bytes.Compare(k, end) < 0; k, v = c.Next() {
+			var r Record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			records = append(records, r)
+		}
+		return nil
+	})
+	return
+}
+
+// DailyEarnings returns the earnings of the node per day in loc between the days
+// of from and to, both inclusive. The earnings of a day are the increase of the
+// lifetime earnings since the last record of a previous day. Days without
+// records are omitted.
+func (l *Ledger) DailyEarnings(id string, from, to time.Time, loc *time.Location) ([]Day, error) {
+	start := StartOfDay(from, loc)
+	end := StartOfDay(to, loc).AddDate(0, 0, 1)
+
+	baseline, records, err := l.Records(id, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	base, hasBase := Record{}, baseline != nil
+	if hasBase {
+		base = *baseline
+	}
+
+	var days []Day
+	for _, r := range records {
+		date := StartOfDay(r.Time, loc)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			if len(days) > 0 {
+				base = days[len(days)-1].Last
+			} else if !hasBase {
+				// the first ever record has no previous value to compare to
+				base = r
+			}
+			days = append(days, Day{Date: date})
+		}
+
+		day := &days[len(days)-1]
+		day.Last = r
+		day.Earnings = r.Total - base.Total
+	}
+
+	return days, nil
+}
+
+// Earnings returns the earnings of the node on the day of t in loc.
+func (l *Ledger) Earnings(id string, t time.Time, loc *time.Location) (float64, error) {
+	days, err := l.DailyEarnings(id, t, t, loc)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(days) == 0 {
+		return 0, nil
+	}
+	return days[0].Earnings, nil
+}
+
+// Settlement is an increase of the settled earnings of a node.
+type Settlement struct {
+	Time   time.Time
+	Amount float64
+}
+
+// Settlements returns the settlements of the node in [from, to), detected by
+// increases of the settled earnings between consecutive records.
+func (l *Ledger) Settlements(id string, from, to time.Time) ([]Settlement, error) {
+	previous, records, err := l.Records(id, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var settlements []Settlement
+	for i := range records {
+		if previous != nil && records[i].Settled > previous.Settled {
+			settlements = append(settlements, Settlement{
+				Time:   records[i].Time,
+				Amount: records[i].Settled - previous.Settled,
+			})
+		}
+		previous = &records[i]
+	}
+
+	return settlements, nil
+}
+
+// StartOfDay returns midnight of the day of t in loc.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// timeKey encodes t as big endian unix nanoseconds, so keys sort chronologically.
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+// Prune deletes earnings records, prices and sessions older than before, and
+// sessions recorded before it from the recording index. The last earnings
+// record of every node and the last prices before the cutoff are kept, so daily
+// earnings and prices of the first retained day stay correct.
+func (l *Ledger) Prune(before time.Time) error {
+	cutoff := timeKey(before)
+	return l.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(earningsBucket).ForEachBucket(func(id []byte) error {
+			return prune(tx.Bucket(earningsBucket).Bucket(id), cutoff, true)
+		}); err != nil {
+			return err
+		}
+
+		if err := prune(tx.Bucket(pricesBucket), cutoff, true); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket(sessionsBucket).ForEachBucket(func(id []byte) error {
+			return prune(tx.Bucket(sessionsBucket).Bucket(id), cutoff, false)
+		}); err != nil {
+			return err
+		}
+
+		return tx.Bucket(recordedBucket).ForEachBucket(func(id []byte) error {
+			return prune(tx.Bucket(recordedBucket).Bucket(id), cutoff, false)
+		})
+	})
+}
+
+// prune deletes the keys of b before cutoff, except for the last one if
+// keepLast is set.
+func prune(b *bolt.Bucket, cutoff []byte, keepLast bool) error {
+	var keys [][]byte
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil && bytes.Compare(k, cutoff) < 0; k, _ = c.Next() {
+		keys = append(keys, bytes.Clone(k))
+	}
+	if keepLast && len(keys) > 0 {
+		keys = keys[:len(keys)-1]
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package ledger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+)
+
+func openLedger(t *testing.T) *Ledger {
+	t.Helper()
+	l, err := Open(filepath.Join(t.TempDir(), "ledger.db"))
+	if err != nil {
+		t.Fatalf("failed to open ledger: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func record(t *testing.T, l *Ledger, at time.Time, total float64, settled float64) {
+	t.Helper()
+	if err := l.Record("0x1", at, node.LifetimeEarnings{Total: total, Settled: settled, Unsettled: total - settled}); err != nil {
+		t.Fatalf("failed to record earnings: %v", err)
+	}
+}
+
+func TestRecords(t *testing.T) {
+	l := openLedger(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, total := range []float64{1, 2, 3, 4} {
+		record(t, l, start.Add(time.Hour*time.Duration(i)), total, 0)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		baseline float64
+		totals   []float64
+	}{
+		{name: "without baseline", from: start, to: start.Add(time.Hour * 2), totals: []float64{1, 2}},
+		{name: "with baseline", from: start.Add(time.Minute), to: start.Add(time.Hour * 3), baseline: 1, totals: []float64{2, 3}},
+		{name: "after last record", from: start.Add(time.Hour * 5), to: start.Add(time.Hour * 6), baseline: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			baseline, records, err := l.Records("0x1", test.from, test.to)
+			if err != nil {
+				t.Fatalf("failed to read records: %v", err)
+			}
+			if (baseline == nil) != (test.baseline == 0) || (baseline != nil && baseline.Total != test.baseline) {
+				t.Errorf("got baseline %+v, want total %g", baseline, test.baseline)
+			}
+			var totals []float64
+			for _, r := range records {
+				totals = append(totals, r.Total)
+			}
+			if len(totals) != len(test.totals) {
+				t.Fatalf("got totals %v, want %v", totals, test.totals)
+			}
+			for i := range totals {
+				if totals[i] != test.totals[i] {
+					t.Errorf("got totals %v, want %v", totals, test.totals)
+				}
+			}
+		})
+	}
+
+	if baseline, records, err := l.Records("0x2", start, start.Add(time.Hour)); err != nil || baseline != nil || records != nil {
+		t.Errorf("got %+v, %+v, %v for an unknown node, want nothing", baseline, records, err)
+	}
+}
+
+func TestDailyEarnings(t *testing.T) {
+	l := openLedger(t)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	// 23:30 UTC is already the next day in Berlin
+	record(t, l, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), 10, 0)
+	record(t, l, time.Date(2026, 1, 1, 23, 30, 0, 0, time.UTC), 12, 0)
+	record(t, l, time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC), 15, 0)
+	record(t, l, time.Date(2026, 1, 4, 12, 0, 0, 0, time.UTC), 16, 0)
+
+	tests := []struct {
+		name     string
+		loc      *time.Location
+		from, to time.Time
+		want     map[string]float64
+	}{
+		{
+			name: "utc",
+			loc:  time.UTC,
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC),
+			// the first record has no previous value, days without records are omitted
+			want: map[string]float64{"2026-01-01": 2, "2026-01-02": 3, "2026-01-04": 1},
+		},
+		{
+			name: "berlin",
+			loc:  berlin,
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, berlin),
+			to:   time.Date(2026, 1, 2, 0, 0, 0, 0, berlin),
+			want: map[string]float64{"2026-01-01": 0, "2026-01-02": 5},
+		},
+		{
+			name: "baseline of a previous day",
+			loc:  time.UTC,
+			from: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC),
+			want: map[string]float64{"2026-01-04": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			days, err := l.DailyEarnings("0x1", test.from, test.to, test.loc)
+			if err != nil {
+				t.Fatalf("failed to read daily earnings: %v", err)
+			}
+			got := make(map[string]float64)
+			for _, day := range days {
+				if day.Date.Location() != test.loc {
+					t.Errorf("got day %s, want it in %s", day.Date, test.loc)
+				}
+				got[day.Date.Format(time.DateOnly)] = day.Earnings
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got earnings %v, want %v", got, test.want)
+			}
+			for date, earnings := range test.want {
+				if got[date] != earnings {
+					t.Errorf("got earnings %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSettlements(t *testing.T) {
+	l := openLedger(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, settled := range []float64{0, 0, 5, 5, 8} {
+		record(t, l, start.Add(time.Hour*time.Duration(i)), 10, settled)
+	}
+
+	settlements, err := l.Settlements("0x1", start.Add(time.Hour*2), start.Add(time.Hour*5))
+	if err != nil {
+		t.Fatalf("failed to read settlements: %v", err)
+	}
+	want := []Settlement{{Time: start.Add(time.Hour * 2), Amount: 5}, {Time: start.Add(time.Hour * 4), Amount: 3}}
+	if len(settlements) != len(want) {
+		t.Fatalf("got settlements %+v, want %+v", settlements, want)
+	}
+	for i := range want {
+		if !settlements[i].Time.Equal(want[i].Time) || settlements[i].Amount != want[i].Amount {
+			t.Errorf("got settlements %+v, want %+v", settlements, want)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	l := openLedger(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		at := start.Add(time.Hour * 24 * time.Duration(i))
+		record(t, l, at, float64(i), 0)
+		if err := l.RecordPrices(at, map[string]float64{"EUR": float64(i)}); err != nil {
+			t.Fatalf("failed to record prices: %v", err)
+		}
+		session := node.Session{ServiceType: "wireguard", ConsumerCountry: "DE", StartedAt: at}
+		if _, err := l.RecordSessions("0x1", at, []node.Session{session}); err != nil {
+			t.Fatalf("failed to record sessions: %v", err)
+		}
+	}
+
+	cutoff := start.Add(time.Hour * 24 * 2)
+	if err := l.Prune(cutoff); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+
+	// the last record and price before the cutoff are kept
+	baseline, records, err := l.Records("0x1", start, cutoff)
+	if err != nil || baseline != nil || len(records) != 1 || records[0].Total != 1 {
+		t.Errorf("got baseline %+v and records %+v, want only the last record before the cutoff", baseline, records)
+	}
+	if price, _, err := l.Price("EUR", cutoff); err != nil || price != 1 {
+		t.Errorf("got price %g (%v), want the last price before the cutoff", price, err)
+	}
+
+	// sessions are not kept
+	sessions, err := l.Sessions("0x1", start, cutoff.Add(time.Hour))
+	if err != nil || len(sessions) != 1 || !sessions[0].StartedAt.Equal(cutoff) {
+		t.Errorf("got sessions %+v, want only the session after the cutoff", sessions)
+	}
+	recorded, err := l.RecordedSessions("0x1", start, cutoff.Add(time.Hour))
+	if err != nil || len(recorded) != 1 || !recorded[0].StartedAt.Equal(cutoff) {
+		t.Errorf("got recorded sessions %+v, want only the session after the cutoff", recorded)
+	}
+}
+
+func TestRecordSessions(t *testing.T) {
+	l := openLedger(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	backlog := []node.Session{
+		{ServiceType: "wireguard", ConsumerCountry: "DE", StartedAt: start},
+		{ServiceType: "scraping", ConsumerCountry: "DE", StartedAt: start},
+	}
+
+	recorded, err := l.RecordSessions("0x1", start, backlog)
+	if err != nil || len(recorded) != 0 {
+		t.Errorf("got %+v (%v) for the backlog, want no sessions", recorded, err)
+	}
+
+	late := node.Session{ServiceType: "wireguard", ConsumerCountry: "US", Earning: 0.5, StartedAt: start.Add(-time.Hour)}
+	recorded, err = l.RecordSessions("0x1", start.Add(time.Hour), append(backlog, late))
+	if err != nil || len(recorded) != 1 || recorded[0] != late {
+		t.Errorf("got %+v (%v), want only the new session", recorded, err)
+	}
+
+	// sessions are stored by start time and indexed by recording time
+	sessions, err := l.Sessions("0x1", start.Add(-time.Hour), start)
+	if err != nil || len(sessions) != 1 || sessions[0] != late {
+		t.Errorf("got sessions %+v (%v), want the late session", sessions, err)
+	}
+	sessions, err = l.RecordedSessions("0x1", start.Add(time.Hour), start.Add(time.Hour*2))
+	if err != nil || len(sessions) != 1 || sessions[0] != late {
+		t.Errorf("got recorded sessions %+v (%v), want the late session", sessions, err)
+	}
+	sessions, err = l.RecordedSessions("0x1", start, start.Add(time.Hour))
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("got recorded sessions %+v (%v), want no backlog sessions", sessions, err)
+	}
+}
+
+func TestPrice(t *testing.T) {
+	l := openLedger(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := l.RecordPrices(start, map[string]float64{"EUR": 0.1, "USD": 0.12}); err != nil {
+		t.Fatalf("failed to record prices: %v", err)
+	}
+	if err := l.RecordPrices(start.Add(time.Hour), map[string]float64{"EUR": 0.2}); err != nil {
+		t.Fatalf("failed to record prices: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		currency string
+		at       time.Time
+		price    float64
+		recorded time.Time
+		err      bool
+	}{
+		{name: "latest", currency: "EUR", at: start.Add(time.Hour * 2), price: 0.2, recorded: start.Add(time.Hour)},
+		{name: "before", currency: "EUR", at: start.Add(time.Minute), price: 0.1, recorded: start},
+		{name: "exact time is excluded", currency: "EUR", at: start.Add(time.Hour), price: 0.1, recorded: start},
+		{name: "missing in later prices", currency: "USD", at: start.Add(time.Hour * 2), price: 0.12, recorded: start},
+		{name: "before the first price", currency: "EUR", at: start, err: true},
+		{name: "unknown currency", currency: "GBP", at: start.Add(time.Hour * 2), err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			price, recorded, err := l.Price(test.currency, test.at)
+			if test.err {
+				if err == nil {
+					t.Errorf("got price %g, want an error", price)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to look up price: %v", err)
+			}
+			if price != test.price || !recorded.Equal(test.recorded) {
+				t.Errorf("got price %g recorded at %s, want %g at %s", price, recorded, test.price, test.recorded)
+			}
+		})
+	}
+}
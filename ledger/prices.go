@@ -0,0 +1,60 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var pricesBucket = []byte("prices")
+
+// ErrNoPrice is returned by Price if no price of the currency was recorded
+// before the time.
+var ErrNoPrice = errors.New("no price recorded")
+
+// RecordPrices stores the MYST token prices by currency.
+func (l *Ledger) RecordPrices(t time.Time, prices map[string]float64) error {
+	value, err := json.Marshal(prices)
+	if err != nil {
+		return err
+	}
+
+	return l.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pricesBucket).Put(timeKey(t), value)
+	})
+}
+
+// Price returns the last MYST token price in currency recorded before t and the
+// time it was recorded at.
+func (l *Ledger) Price(currency string, t time.Time) (float64, time.Time, error) {
+	var price float64
+	var at time.Time
+	err := l.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(pricesBucket).Cursor()
+
+		k, v := c.Seek(timeKey(t))
+		if k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+
+		for ; k != nil; k, v = c.Prev() {
+			prices := make(map[string]float64)
+			if err := json.Unmarshal(v, &prices); err != nil {
+				return err
+			}
+			if p, ok := prices[currency]; ok {
+				price = p
+				at = time.Unix(0, int64(binary.BigEndian.Uint64(k)))
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s before %s", ErrNoPrice, currency, t.Format(time.DateTime))
+	})
+	return price, at, err
+}
@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+)
+
+var (
+	sessionsBucket = []byte("sessions")
+	// recordedBucket indexes the sessions of a node by the time they were
+	// recorded, which is later than their start if the api returns them late.
+	recordedBucket = []byte("sessions_recorded")
+)
+
+// session is the stored form of a node.Session, which can not be round tripped
+// through its own JSON encoding.
+type session struct {
+	ConsumerCountry string        `json:"consumerCountry"`
+	ServiceType     string        `json:"serviceType"`
+	Duration        time.Duration `json:"duration"`
+	Earning         float64       `json:"earning"`
+	Transferred     int64         `json:"transferred"`
+	StartedAt       time.Time     `json:"startedAt"`
+}
+
+// RecordSessions stores the sessions of the node observed at t and returns the
+// ones that were not recorded before. Sessions are identified by start time,
+// service and consumer country. The first sessions recorded for a node are the
+// backlog of the sessions api, they are stored but neither returned nor
+// indexed by recording time.
+func (l *Ledger) RecordSessions(id string, t time.Time, sessions []node.Session) ([]node.Session, error) {
+	var recorded []node.Session
+	err := l.db.Update(func(tx *bolt.Tx) error {
+		known := tx.Bucket(sessionsBucket).Bucket([]byte(id)) != nil
+		b, err := tx.Bucket(sessionsBucket).CreateBucketIfNotExists([]byte(id))
+		if err != nil {
+			return err
+		}
+		index, err := tx.Bucket(recordedBucket).CreateBucketIfNotExists([]byte(id))
+		if err != nil {
+			return err
+		}
+
+		for _, s := range sessions {
+			key := sessionKey(s)
+			if b.Get(key) != nil {
+				continue
+			}
+
+			value, err := json.Marshal(session(s))
+			if err != nil {
+				return err
+			}
+			if err := b.Put(key, value); err != nil {
+				return err
+			}
+			if known {
+				if err := index.Put(append(timeKey(t), key...), []byte{}); err != nil {
+					return err
+				}
+				recorded = append(recorded, s)
+			}
+		}
+		return nil
+	})
+	return recorded, err
+}
+
+// Sessions returns the recorded sessions of the node started in [from, to).
+func (l *Ledger) Sessions(id string, from, to time.Time) ([]node.Session, error) {
+	var sessions []node.Session
+	err := l.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sessionsBucket).Bucket([]byte(id))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		start, end := timeKey(from), timeKey(to)
+		for k, v := c.Seek(start); k != nil && bytes.Compare(k[:8], end) < 0; k, v = c.Next() {
+			var s session
+			if err := json.Unmarshal(v, &s); err != nil {
+				return err
+			}
+			sessions = append(sessions, node.Session(s))
+		}
+		return nil
+	})
+	return sessions, err
+}
+
+// RecordedSessions returns the sessions of the node recorded in [from, to),
+// regardless of when they started.
+func (l *Ledger) RecordedSessions(id string, from, to time.Time) ([]node.Session, error) {
+	var sessions []node.Session
+	err := l.db.View(func(tx *bolt.Tx) error {
+		// ledgers of older versions opened read only have no index
+		index := tx.Bucket(recordedBucket)
+		if index == nil || index.Bucket([]byte(id)) == nil {
+			return nil
+		}
+		b := tx.Bucket(sessionsBucket).Bucket([]byte(id))
+
+		c := index.Bucket([]byte(id)).Cursor()
+		start, end := timeKey(from), timeKey(to)
+		for k, _ := c.Seek(start); k != nil && bytes.Compare(k[:8], end) < 0; k, _ = c.Next() {
+			// the session may have been pruned by its start time already
+			v := b.Get(k[8:])
+			if v == nil {
+				continue
+			}
+
+			var s session
+			if err := json.Unmarshal(v, &s); err != nil {
+				return err
+			}
+			sessions = append(sessions, node.Session(s))
+		}
+		return nil
+	})
+	return sessions, err
+}
+
+// sessionKey orders sessions by start time.
+func sessionKey(s node.Session) []byte {
+	key := timeKey(s.StartedAt)
+	key = append(key, s.ServiceType...)
+	key = append(key, 0)
+	return append(key, s.ConsumerCountry...)
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+	"github.com/sch8ill/mystprom/config"
+)
+
+var nodePeakConcurrentSessions = newGaugeVec(Metric{
+	Name:   "myst_node_peak_concurrent_sessions",
+	Help:   "Peak number of concurrent sessions of the node within the window",
+	Labels: []string{"id", "name", "window"},
+	Source: SourceSessions,
+})
+
+var nodeSessionThroughput = newGaugeVec(Metric{
+	Name:   "myst_node_session_throughput_bytes_per_second",
+	Help:   "Average throughput of sessions of the node by service over the last 30 days",
+	Unit:   "bytes_per_second",
+	Labels: []string{"id", "name", "service"},
+	Source: SourceSessions,
+})
+
+var nodeSessionActivity = newGaugeVec(Metric{
+	Name:   "myst_node_session_activity",
+	Help:   "Number of sessions of the node started by weekday and hour of day over the last 30 days",
+	Labels: []string{"id", "name", "weekday", "hour"},
+	Source: SourceSessions,
+})
+
+var fleetSessionActivity = newGaugeVec(Metric{
+	Name:   "myst_fleet_session_activity",
+	Help:   "Number of sessions of all nodes started by weekday and hour of day over the last 30 days",
+	Labels: []string{"weekday", "hour"},
+	Source: SourceSessions,
+})
+
+func init() {
+	registry.MustRegister(nodePeakConcurrentSessions, nodeSessionThroughput, nodeSessionActivity,
+		fleetSessionActivity)
+}
+
+// NodeSessionActivity exports when and how the sessions of the node are used.
+// Weekdays and hours are calculated in loc. The activity by weekday and hour is
+// only exported per node if enabled, as it has 168 series per node.
+func NodeSessionActivity(id string, name string, sessions []node.Session, windows []config.Window, now time.Time, loc *time.Location) {
+	for _, window := range windows {
+		peak := peakConcurrency(sessions, now.Add(-window.Duration), now)
+		nodePeakConcurrentSessions.WithLabelValues(id, name, window.Name).Set(float64(peak))
+	}
+
+	throughput := make(map[string]float64)
+	measured := make(map[string]int)
+	for _, session := range sessions {
+		if session.Duration > 0 {
+			throughput[session.ServiceType] += float64(session.Transferred) / session.Duration.Seconds()
+			measured[session.ServiceType]++
+		}
+	}
+
+	nodeSessionThroughput.DeletePartialMatch(prometheus.Labels{"id": id})
+	for service, total := range throughput {
+		nodeSessionThroughput.WithLabelValues(id, name, service).Set(total / float64(measured[service]))
+	}
+
+	if config.NodeActivity {
+		setActivity(sessions, loc, func(weekday string, hour string) prometheus.Gauge {
+			return nodeSessionActivity.WithLabelValues(id, name, weekday, hour)
+		})
+	}
+}
+
+// FleetSessionActivity exports the number of sessions of all nodes started by
+// weekday and hour of day in loc.
+func FleetSessionActivity(sessions map[string][]node.Session, loc *time.Location) {
+	setActivity(flatten(sessions), loc, func(weekday string, hour string) prometheus.Gauge {
+		return fleetSessionActivity.WithLabelValues(weekday, hour)
+	})
+}
+
+// setActivity counts the sessions started by weekday and hour. Every weekday
+// and hour is exported, so inactive times show up as 0.
+func setActivity(sessions []node.Session, loc *time.Location, gauge func(weekday string, hour string) prometheus.Gauge) {
+	activity := make(map[time.Weekday]map[int]int)
+	for _, session := range sessions {
+		started := session.StartedAt.In(loc)
+		if activity[started.Weekday()] == nil {
+			activity[started.Weekday()] = make(map[int]int)
+		}
+		activity[started.Weekday()][started.Hour()]++
+	}
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		for hour := 0; hour < 24; hour++ {
+			gauge(weekday.String(), strconv.Itoa(hour)).Set(float64(activity[weekday][hour]))
+		}
+	}
+}
+
+// peakConcurrency returns the maximum number of sessions overlapping at any time
+// within [start, end].
+func peakConcurrency(sessions []node.Session, start, end time.Time) int {
+	type event struct {
+		at    time.Time
+		delta int
+	}
+
+	var events []event
+	for _, session := range sessions {
+		from := session.StartedAt
+		to := session.StartedAt.Add(session.Duration)
+		if to.Before(start) || from.After(end) {
+			continue
+		}
+
+		if from.Before(start) {
+			from = start
+		}
+		events = append(events, event{at: from, delta: 1}, event{at: to, delta: -1})
+	}
+
+	// sessions ending at the same time another one starts do not overlap
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at.Before(events[j].at)
+	})
+
+	var current, peak int
+	for _, e := range events {
+		current += e.delta
+		peak = max(peak, current)
+	}
+	return peak
+}
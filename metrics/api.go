@@ -4,7 +4,7 @@ import (
 	"net/http"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 
 	"github.com/sch8ill/mystprom/config"
 )
@@ -13,10 +13,15 @@ import (
 var registry = prometheus.NewRegistry()
 
 func Listen() error {
-	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	http.Handle("/metrics", handler())
 	if err := http.ListenAndServe(config.MetricsAddress, nil); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Gather returns the current state of the exported metrics.
+func Gather() ([]*dto.MetricFamily, error) {
+	return catalogGatherer{registry}.Gather()
+}
@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"golang.org/x/exp/slices"
+
+	"github.com/sch8ill/mystprom/api/mystnodes"
+)
+
+// Type is the type of a metric.
+type Type string
+
+const (
+	Gauge     Type = "gauge"
+	Counter   Type = "counter"
+	Histogram Type = "histogram"
+	// StateSet metrics have one series per state with a label named after the
+	// metric, which is 1 for the current state and 0 for all others.
+	StateSet Type = "stateset"
+	// Info metrics are gauges that are always 1 and carry information in labels.
+	// Their names end with _info, the OpenMetrics family name is without it.
+	Info Type = "info"
+)
+
+// Sources the metrics are derived from.
+const (
+	SourceNodes        = mystnodes.NodePath
+	SourceNode         = mystnodes.NodePath + "/{identity}"
+	SourceSessions     = mystnodes.NodePath + "/{identity}/sessions"
+	SourceTotals       = mystnodes.TotalsPath
+	SourceGlobalStats  = mystnodes.GlobalStatsPath
+	SourceRewardPoints = mystnodes.RewardPointsPath
+	SourceRewardStats  = mystnodes.RewardStatsPath
+	SourceRewardRanks  = mystnodes.RewardRanksPath
+	SourcePrices       = "price sources"
+	SourceLedger       = "ledger"
+	SourceGeoIP        = "geoip database"
+	SourceExporter     = "exporter"
+)
+
+// Metric schemes a metric is part of. Metrics without scheme are part of both.
+const (
+	SchemeV1 = "v1"
+	SchemeV2 = "v2"
+)
+
+// Metric describes an exported metric.
+type Metric struct {
+	Name string `json:"name"`
+	Help string `json:"help"`
+	// Unit is the OpenMetrics unit of the metric, which has to be a suffix of
+	// the name, like bytes or seconds.
+	Unit string `json:"unit,omitempty"`
+	// DisplayUnit is the unit the values are shown in, like MYST or unixtime.
+	// It defaults to the OpenMetrics unit.
+	DisplayUnit string   `json:"display_unit,omitempty"`
+	Type        Type     `json:"type"`
+	Labels      []string `json:"labels"`
+	// Source is the api endpoint or other source the metric is derived from.
+	Source string `json:"source"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// Catalog returns every exported metric sorted by name.
+func Catalog() []Metric {
+	metrics := make([]Metric, 0, len(catalog))
+	for _, m := range catalog {
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Name < metrics[j].Name
+	})
+	return metrics
+}
+
+// Lookup returns the metric with the given name from the catalog.
+func Lookup(name string) (Metric, bool) {
+	m, ok := catalog[name]
+	return m, ok
+}
+
+// familyName returns the name of the OpenMetrics metric family, which omits the
+// _total suffix of counters and the _info suffix of info metrics.
+func (m Metric) familyName() string {
+	switch m.Type {
+	case Counter:
+		return strings.TrimSuffix(m.Name, "_total")
+	case Info:
+		return strings.TrimSuffix(m.Name, "_info")
+	default:
+		return m.Name
+	}
+}
+
+// catalog holds every metric created with the constructors below.
+var catalog = make(map[string]Metric)
+
+func describe(m Metric, t Type) Metric {
+	if m.Type == "" {
+		m.Type = t
+	}
+	if m.Labels == nil {
+		m.Labels = []string{}
+	}
+	if m.Type == Info && !strings.HasSuffix(m.Name, "_info") {
+		panic("info metric without _info suffix: " + m.Name)
+	}
+	if m.Type == StateSet && !slices.Contains(m.Labels, m.Name) {
+		panic("state set without state label: " + m.Name)
+	}
+	if m.Unit != "" && !strings.HasSuffix(m.familyName(), "_"+m.Unit) {
+		panic("unit is not a suffix of the metric name: " + m.Name)
+	}
+	if m.DisplayUnit == "" {
+		m.DisplayUnit = m.Unit
+	}
+	catalog[m.Name] = m
+	return m
+}
+
+func newGauge(m Metric) prometheus.Gauge {
+	m = describe(m, Gauge)
+	return prometheus.NewGauge(prometheus.GaugeOpts{Name: m.Name, Help: m.Help})
+}
+
+// optionalGauge is a gauge without labels that is only registered, and thereby
+// exported, while it has a value.
+type optionalGauge struct {
+	gauge prometheus.Gauge
+
+	mu         sync.Mutex
+	registered bool
+}
+
+func newOptionalGauge(m Metric) *optionalGauge {
+	m = describe(m, Gauge)
+	return &optionalGauge{gauge: prometheus.NewGauge(prometheus.GaugeOpts{Name: m.Name, Help: m.Help})}
+}
+
+func (g *optionalGauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.registered {
+		registry.MustRegister(g.gauge)
+		g.registered = true
+	}
+	g.gauge.Set(value)
+}
+
+// Clear stops exporting the gauge until it is set again.
+func (g *optionalGauge) Clear() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.registered {
+		registry.Unregister(g.gauge)
+		g.registered = false
+	}
+}
+
+func newGaugeVec(m Metric) *prometheus.GaugeVec {
+	m = describe(m, Gauge)
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: m.Name, Help: m.Help}, m.Labels)
+}
+
+func newCounterVec(m Metric) *prometheus.CounterVec {
+	m = describe(m, Counter)
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: m.Name, Help: m.Help}, m.Labels)
+}
+
+// newHistogramVec creates a histogram with the buckets of opts.
+func newHistogramVec(m Metric, opts prometheus.HistogramOpts) *prometheus.HistogramVec {
+	m = describe(m, Histogram)
+	opts.Name = m.Name
+	opts.Help = m.Help
+	return prometheus.NewHistogramVec(opts, m.Labels)
+}
+
+// catalogGatherer adds the units of the catalog to the gathered metric families.
+type catalogGatherer struct {
+	prometheus.Gatherer
+}
+
+func (g catalogGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.Gatherer.Gather()
+	for _, family := range families {
+		if m, ok := catalog[family.GetName()]; ok && m.Unit != "" {
+			family.Unit = &m.Unit
+		}
+	}
+	return families, err
+}
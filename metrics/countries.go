@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+	"github.com/sch8ill/mystprom/config"
+	"github.com/sch8ill/mystprom/countries"
+)
+
+var fleetSessions = newGaugeVec(Metric{
+	Name:   "myst_fleet_sessions",
+	Help:   "Number of sessions of all nodes by service and country over the last 30 days",
+	Labels: []string{"service", "country", "continent", "country_name"},
+	Source: SourceSessions,
+})
+
+var fleetSessionEarnings = newGaugeVec(Metric{
+	Name:        "myst_fleet_session_earnings",
+	Help:        "Earnings of all nodes by service and country generated from session log",
+	DisplayUnit: "MYST",
+	Labels:      []string{"service", "country", "continent", "country_name"},
+	Source:      SourceSessions,
+})
+
+var fleetSessionTraffic = newGaugeVec(Metric{
+	Name:        "myst_fleet_session_traffic",
+	Help:        "Traffic served by all nodes by service and country, generated from session log",
+	DisplayUnit: "gigabytes",
+	Labels:      []string{"service", "country", "continent", "country_name"},
+	Source:      SourceSessions,
+	Scheme:      SchemeV1,
+})
+
+var fleetSessionDurations = newGaugeVec(Metric{
+	Name:        "myst_fleet_session_durations",
+	Help:        "Total duration of sessions of all nodes by service and country over the last 30 days",
+	DisplayUnit: "seconds",
+	Labels:      []string{"service", "country", "continent", "country_name"},
+	Source:      SourceSessions,
+	Scheme:      SchemeV1,
+})
+
+func init() {
+	registry.MustRegister(fleetSessions, fleetSessionEarnings, fleetSessionTraffic, fleetSessionDurations)
+}
+
+// FleetSessions exports the sessions of all nodes by country without node labels.
+func FleetSessions(sessions map[string][]node.Session) {
+	type filter struct {
+		service string
+		country string
+	}
+
+	all := flatten(sessions)
+	top := topCountries(all, config.CountryTopN)
+
+	sessionCount := make(map[filter]int)
+	traffic := make(map[filter]float64)
+	durations := make(map[filter]time.Duration)
+	earnings := make(map[filter]float64)
+
+	for _, session := range all {
+		f := filter{
+			service: session.ServiceType,
+			country: foldCountry(session.ConsumerCountry, top),
+		}
+
+		sessionCount[f]++
+		traffic[f] += float64(session.Transferred)
+		durations[f] += session.Duration
+		earnings[f] += session.Earning
+	}
+
+	fleetSessions.Reset()
+	fleetSessionEarnings.Reset()
+	fleetSessionTraffic.Reset()
+	fleetSessionDurations.Reset()
+	fleetSessionsTrafficBytes.Reset()
+	fleetSessionsDurationSeconds.Reset()
+
+	for f, total := range sessionCount {
+		labels := append([]string{f.service}, countryLabels(f.country)...)
+		fleetSessions.WithLabelValues(labels...).Set(float64(total))
+		if config.MetricsV1 {
+			fleetSessionTraffic.WithLabelValues(labels...).Set(traffic[f] * 1e-9) // convert bytes to GB
+			fleetSessionDurations.WithLabelValues(labels...).Set(durations[f].Seconds())
+		}
+		if config.MetricsV2 {
+			fleetSessionsTrafficBytes.WithLabelValues(labels...).Set(traffic[f])
+			fleetSessionsDurationSeconds.WithLabelValues(labels...).Set(durations[f].Seconds())
+		}
+		fleetSessionEarnings.WithLabelValues(labels...).Set(earnings[f])
+	}
+}
+
+// flatten returns the sessions of all nodes.
+func flatten(sessions map[string][]node.Session) []node.Session {
+	var all []node.Session
+	for _, s := range sessions {
+		all = append(all, s...)
+	}
+	return all
+}
+
+// topCountries returns the n countries with the most sessions. It returns nil
+// if n is not positive, which keeps all countries.
+func topCountries(sessions []node.Session, n int) map[string]bool {
+	if n <= 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, session := range sessions {
+		counts[countries.Normalize(session.ConsumerCountry)]++
+	}
+
+	codes := make([]string, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if counts[codes[i]] == counts[codes[j]] {
+			return codes[i] < codes[j]
+		}
+		return counts[codes[i]] > counts[codes[j]]
+	})
+
+	top := make(map[string]bool)
+	for _, code := range codes[:min(n, len(codes))] {
+		top[code] = true
+	}
+	return top
+}
+
+// foldCountry normalizes the country code and folds countries outside of top
+// into other, unless top is nil.
+func foldCountry(code string, top map[string]bool) string {
+	code = countries.Normalize(code)
+	if top != nil && !top[code] {
+		return countries.Other
+	}
+	return code
+}
+
+// countryLabels returns the country, continent and country_name label values.
+// The continent and country name are only set if country details are enabled.
+func countryLabels(code string) []string {
+	if !config.CountryDetails || code == "" {
+		return []string{code, "", ""}
+	}
+
+	c := countries.Lookup(code)
+	return []string{code, c.Continent, c.Name}
+}
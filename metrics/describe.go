@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown writes the metrics as a markdown table.
+func WriteMarkdown(w io.Writer, metrics []Metric) error {
+	if _, err := fmt.Fprintln(w, "| name | description | labels | type | unit | source | scheme |"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "|------|-------------|--------|------|------|--------|--------|"); err != nil {
+		return err
+	}
+
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s |\n", m.Name, m.Help,
+			strings.Join(m.Labels, ", "), m.Type, m.DisplayUnit, m.Source, m.Scheme); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the metrics as a json array.
+func WriteJSON(w io.Writer, metrics []Metric) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(metrics)
+}
+
+var metricNamePattern = regexp.MustCompile(`\bmyst_[a-zA-Z0-9_]+`)
+
+// suffixes of the series of histograms and the OpenMetrics created series
+var seriesSuffixes = []string{"_bucket", "_sum", "_count", "_created"}
+
+// LintDashboard returns the metric names referenced in the dashboard that are
+// not in the catalog, sorted by name.
+func LintDashboard(dashboard io.Reader) ([]string, error) {
+	data, err := io.ReadAll(dashboard)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("dashboard is not valid json")
+	}
+
+	unknown := make(map[string]bool)
+	for _, name := range metricNamePattern.FindAllString(string(data), -1) {
+		if !knownSeries(name) {
+			unknown[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func knownSeries(name string) bool {
+	if _, ok := catalog[name]; ok {
+		return true
+	}
+	for _, suffix := range seriesSuffixes {
+		base, found := strings.CutSuffix(name, suffix)
+		if !found {
+			continue
+		}
+		if m, ok := catalog[base]; ok && m.Type == Histogram {
+			return true
+		}
+		// the created series of counters replaces the _total suffix
+		if m, ok := catalog[base+"_total"]; ok && m.Type == Counter && suffix == "_created" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/rs/zerolog/log"
+)
+
+// handler serves the metrics in the format negotiated with the scraper.
+// OpenMetrics is encoded here instead of by promhttp, as the client library
+// has no state set and info types and exports them as gauges.
+func handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		families, err := Gather()
+		if err != nil {
+			http.Error(w, "failed to gather metrics: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		format := expfmt.NegotiateIncludingOpenMetrics(r.Header)
+		w.Header().Set("Content-Type", string(format))
+
+		var out io.Writer = w
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			defer gz.Close()
+			out = gz
+		}
+
+		if err := encode(out, format, families); err != nil {
+			log.Debug().Err(err).Msg("failed to write metrics")
+		}
+	})
+}
+
+func encode(w io.Writer, format expfmt.Format, families []*dto.MetricFamily) error {
+	if format.FormatType() != expfmt.TypeOpenMetrics {
+		enc := expfmt.NewEncoder(w, format)
+		for _, family := range families {
+			if err := enc.Encode(family); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scheme := format.ToEscapingScheme()
+	for _, family := range families {
+		if err := encodeOpenMetrics(w, model.EscapeMetricFamily(family, scheme)); err != nil {
+			return err
+		}
+	}
+	_, err := expfmt.FinalizeOpenMetrics(w)
+	return err
+}
+
+// encodeOpenMetrics writes the family in the OpenMetrics text format. State sets
+// and info metrics, which are gathered as gauges, are retyped in their metadata.
+// Their samples are the same as those of the gauges.
+func encodeOpenMetrics(w io.Writer, family *dto.MetricFamily) error {
+	m, ok := catalog[family.GetName()]
+	if !ok || (m.Type != StateSet && m.Type != Info) {
+		_, err := expfmt.MetricFamilyToOpenMetrics(w, family, expfmt.WithUnit(), expfmt.WithCreatedLines())
+		return err
+	}
+
+	var buf bytes.Buffer
+	if _, err := expfmt.MetricFamilyToOpenMetrics(&buf, family); err != nil {
+		return err
+	}
+
+	name, exposed := family.GetName(), m.familyName()
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# HELP "+name+" "):
+			line = "# HELP " + exposed + strings.TrimPrefix(line, "# HELP "+name)
+		case strings.HasPrefix(line, "# TYPE "+name+" "):
+			line = "# TYPE " + exposed + " " + string(m.Type) + "\n"
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
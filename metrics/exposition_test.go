@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// TestOpenMetricsFamiliesUnique encodes a series of every catalog metric in the
+// OpenMetrics format and checks that no two metrics share a family or series name,
+// which makes Prometheus reject the whole scrape.
+func TestOpenMetricsFamiliesUnique(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	for _, m := range Catalog() {
+		values := make([]string, len(m.Labels))
+		for i := range values {
+			values[i] = "value"
+		}
+
+		switch m.Type {
+		case Counter:
+			c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: m.Name, Help: m.Help}, m.Labels)
+			c.WithLabelValues(values...).Inc()
+			reg.MustRegister(c)
+		case Histogram:
+			h := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: m.Name, Help: m.Help}, m.Labels)
+			h.WithLabelValues(values...).Observe(1)
+			reg.MustRegister(h)
+		default:
+			g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: m.Name, Help: m.Help}, m.Labels)
+			g.WithLabelValues(values...).Set(1)
+			reg.MustRegister(g)
+		}
+	}
+
+	families, err := catalogGatherer{reg}.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := encode(&buf, expfmt.NewFormat(expfmt.TypeOpenMetrics), families); err != nil {
+		t.Fatalf("failed to encode metrics: %v", err)
+	}
+
+	types := make(map[string]int)
+	series := make(map[string]string)
+	var family string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# TYPE "):
+			family = strings.Fields(line)[2]
+			types[family]++
+		case line == "", strings.HasPrefix(line, "#"):
+		default:
+			name, _, _ := strings.Cut(line, "{")
+			if other, ok := series[name]; ok && other != family {
+				t.Errorf("series %s is part of the families %s and %s", name, other, family)
+			}
+			series[name] = family
+		}
+	}
+
+	for name, n := range types {
+		if n > 1 {
+			t.Errorf("family %s is exported %d times", name, n)
+		}
+	}
+	if len(types) != len(families) {
+		t.Errorf("got %d families in the output, want %d", len(types), len(families))
+	}
+}
+
+// TestOpenMetricsTypes checks the metadata of retyped and unit metrics.
+func TestOpenMetricsTypes(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	status := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "myst_node_monitoring_status_state", Help: "status"},
+		[]string{"id", "name", "myst_node_monitoring_status_state"})
+	status.WithLabelValues("id", "name", "online").Set(1)
+	info := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "myst_node_info", Help: "info"}, []string{"id"})
+	info.WithLabelValues("id").Set(1)
+	traffic := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "myst_node_observed_session_traffic_bytes_total",
+		Help: "traffic"}, []string{"id"})
+	traffic.WithLabelValues("id").Add(1)
+	reg.MustRegister(status, info, traffic)
+
+	families, err := catalogGatherer{reg}.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := encode(&buf, expfmt.NewFormat(expfmt.TypeOpenMetrics), families); err != nil {
+		t.Fatalf("failed to encode metrics: %v", err)
+	}
+
+	for _, want := range []string{
+		"# TYPE myst_node_monitoring_status_state stateset\n",
+		"# TYPE myst_node info\n",
+		"# HELP myst_node info\n",
+		"myst_node_info{id=\"id\"} 1.0\n",
+		"# TYPE myst_node_observed_session_traffic_bytes counter\n",
+		"# UNIT myst_node_observed_session_traffic_bytes bytes\n",
+		"# EOF\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/exp/slices"
+
+	"github.com/sch8ill/mystprom/config"
+)
+
+var nodeEarningsFiat = newGaugeVec(Metric{
+	Name:        "myst_node_earnings_fiat",
+	Help:        "Earnings by node and service over the last 30 days valued in currency",
+	DisplayUnit: "currency",
+	Labels:      []string{"id", "name", "service", "currency"},
+	Source:      SourceNodes,
+})
+
+var nodeSessionEarningsFiat = newGaugeVec(Metric{
+	Name:        "myst_node_session_earnings_fiat",
+	Help:        "Earnings by node, service and country generated from session log valued in currency",
+	DisplayUnit: "currency",
+	Labels:      []string{"id", "name", "service", "country", "continent", "country_name", "currency"},
+	Source:      SourceSessions,
+})
+
+var nodeLifetimeEarningsFiat = newGaugeVec(Metric{
+	Name:        "myst_node_earnings_lifetime_fiat",
+	Help:        "Total lifetime earnings by node valued in currency",
+	DisplayUnit: "currency",
+	Labels:      []string{"id", "name", "currency"},
+	Source:      SourceNode,
+})
+
+var nodeSettledEarningsFiat = newGaugeVec(Metric{
+	Name:        "myst_node_earnings_settled_fiat",
+	Help:        "Total settled earnings by node valued in currency",
+	DisplayUnit: "currency",
+	Labels:      []string{"id", "name", "currency"},
+	Source:      SourceNode,
+})
+
+var nodeUnsettledEarningsFiat = newGaugeVec(Metric{
+	Name:        "myst_node_earnings_unsettled_fiat",
+	Help:        "Unsettled earnings by node valued in currency",
+	DisplayUnit: "currency",
+	Labels:      []string{"id", "name", "currency"},
+	Source:      SourceNode,
+})
+
+// latestPrices holds the last known MYST token prices by currency. It is only
+// accessed from the monitor goroutine.
+var latestPrices = make(map[string]float64)
+
+func init() {
+	registry.MustRegister(nodeEarningsFiat, nodeSessionEarningsFiat, nodeLifetimeEarningsFiat,
+		nodeSettledEarningsFiat, nodeUnsettledEarningsFiat)
+}
+
+// setFiat sets the MYST amount valued in every configured currency. Series of
+// currencies without a known price are removed.
+func setFiat(gauge *prometheus.GaugeVec, amount float64, labels ...string) {
+	for _, currency := range config.PriceCurrencies {
+		lvs := append(slices.Clone(labels), currency)
+
+		price, ok := latestPrices[currency]
+		if !ok {
+			gauge.DeleteLabelValues(lvs...)
+			continue
+		}
+		gauge.WithLabelValues(lvs...).Set(amount * price)
+	}
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sch8ill/mystprom/geoip"
+)
+
+var nodeASN = newGaugeVec(Metric{
+	Name:   "myst_node_asn_info",
+	Help:   "Autonomous system of the external ip address of the node",
+	Type:   Info,
+	Labels: []string{"id", "name", "asn", "as_org"},
+	Source: SourceGeoIP,
+})
+
+var nodeCity = newGaugeVec(Metric{
+	Name:   "myst_node_city_info",
+	Help:   "City of the external ip address of the node",
+	Type:   Info,
+	Labels: []string{"id", "name", "city", "country"},
+	Source: SourceGeoIP,
+})
+
+var nodeLatitude = newGaugeVec(Metric{
+	Name:        "myst_node_latitude",
+	Help:        "Approximate latitude of the external ip address of the node",
+	DisplayUnit: "degrees",
+	Labels:      []string{"id", "name"},
+	Source:      SourceGeoIP,
+})
+
+var nodeLongitude = newGaugeVec(Metric{
+	Name:        "myst_node_longitude",
+	Help:        "Approximate longitude of the external ip address of the node",
+	DisplayUnit: "degrees",
+	Labels:      []string{"id", "name"},
+	Source:      SourceGeoIP,
+})
+
+var fleetASNNodes = newGaugeVec(Metric{
+	Name:   "myst_fleet_asn_nodes",
+	Help:   "Number of nodes by autonomous system",
+	Labels: []string{"asn", "as_org"},
+	Source: SourceGeoIP,
+})
+
+func init() {
+	registry.MustRegister(nodeASN, nodeCity, nodeLatitude, nodeLongitude, fleetASNNodes)
+}
+
+// NodeGeoIP exports the autonomous system and location of a node.
+func NodeGeoIP(id string, name string, info geoip.Info) {
+	// the external ip address and thereby the labels may change between cycles
+	for _, gauge := range []*prometheus.GaugeVec{nodeASN, nodeCity, nodeLatitude, nodeLongitude} {
+		gauge.DeletePartialMatch(prometheus.Labels{"id": id})
+	}
+
+	if info.ASN != 0 {
+		nodeASN.WithLabelValues(id, name, strconv.FormatUint(uint64(info.ASN), 10), info.ASOrg).Set(1)
+	}
+	if info.City != "" || info.Country != "" {
+		nodeCity.WithLabelValues(id, name, info.City, info.Country).Set(1)
+	}
+	if info.HasLocation {
+		nodeLatitude.WithLabelValues(id, name).Set(info.Latitude)
+		nodeLongitude.WithLabelValues(id, name).Set(info.Longitude)
+	}
+}
+
+// FleetASNs exports the number of nodes per autonomous system.
+func FleetASNs(infos []geoip.Info) {
+	type as struct {
+		asn string
+		org string
+	}
+
+	counts := make(map[as]int)
+	for _, info := range infos {
+		if info.ASN == 0 {
+			continue
+		}
+		counts[as{strconv.FormatUint(uint64(info.ASN), 10), info.ASOrg}]++
+	}
+
+	fleetASNNodes.Reset()
+	for a, count := range counts {
+		fleetASNNodes.WithLabelValues(a.asn, a.org).Set(float64(count))
+	}
+}
@@ -9,254 +9,450 @@ import (
 
 	stats "github.com/sch8ill/mystprom/api/mystnodes/global-stats"
 	"github.com/sch8ill/mystprom/api/mystnodes/node"
-	"github.com/sch8ill/mystprom/api/mystnodes/rewards"
 	"github.com/sch8ill/mystprom/api/mystnodes/totals"
+	"github.com/sch8ill/mystprom/config"
+	"github.com/sch8ill/mystprom/prices"
 )
 
-var nodeCount = prometheus.NewGauge(prometheus.GaugeOpts{
-	Name: "myst_node_count",
-	Help: "Total number of nodes",
-})
-
-var nodeBandwidth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_bandwidth",
-	Help: "Internet bandwidth of the node",
-}, []string{"id", "name"})
-
-var nodeTraffic = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_traffic",
-	Help: "Traffic transferred by the node over the last 30 days",
-}, []string{"id", "name"})
-
-var nodeUserID = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_user_id",
-	Help: "User ID of user of the node",
-}, []string{"id", "name", "user_id"})
-
-var nodeTermsVersion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_terms_version",
-	Help: "Terms version of the node",
-}, []string{"id", "name", "version"})
-
-var nodeTermsAcceptedAt = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_terms_accepted_at",
-	Help: "Last time terms were accepted by node",
-}, []string{"id", "name"})
-
-var nodeLocalIP = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_local_ip",
-	Help: "Local ip address of the node",
-}, []string{"id", "name", "ip"})
-
-var nodeExternalIP = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_external_ip",
-	Help: "External ip address of the node",
-}, []string{"id", "name", "ip"})
-
-var nodeISP = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_isp",
-	Help: "Internet Service Provider of the node",
-}, []string{"id", "name", "isp"})
-
-var nodeOS = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_os",
-	Help: "Operating system the node is running on",
-}, []string{"id", "name", "os"})
-
-var nodeArch = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_arch",
-	Help: "System architecture of the node",
-}, []string{"id", "name", "arch"})
-
-var nodeVersion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_version",
-	Help: "Myst version the node is running on",
-}, []string{"id", "name", "version"})
-
-var nodeVendor = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_vendor",
-	Help: "Vendor of the node",
-}, []string{"id", "name", "vendor"})
-
-var nodeMalicious = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_malicious",
-	Help: "Whether the node is tagged a malicious",
-}, []string{"id", "name"})
-
-var nodeAvailableAt = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_available_at",
-	Help: "Last time the node was available",
-}, []string{"id", "name"})
-
-var nodeCreatedAt = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_created_at",
-	Help: "Time the node was created",
-}, []string{"id", "name"})
-
-var nodeUpdatedAt = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_updated_at",
-	Help: "Last time the node was updated",
-}, []string{"id", "name"})
-
-var nodeDeleted = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_deleted",
-	Help: "Whether the node is deleted",
-}, []string{"id", "name"})
-
-var nodeLauncherVersion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_launcher_version",
-	Help: "Launcher version the node is running on",
-}, []string{"id", "name", "version"})
-
-var nodeIPTagged = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_ip_tagged",
-	Help: "Whether the node is ip tagged",
-}, []string{"id", "name"})
-
-var nodeMonitoringFailed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_monitoring_failed",
-	Help: "Whether monitoring on the node failed",
-}, []string{"id", "name"})
-
-var nodeMonitoringFailedLastAt = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_monitoring_failed_last_at",
-	Help: "Last time monitoring failed on node",
-}, []string{"id", "name"})
-
-var nodeOnline = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_online",
-	Help: "Whether the node is online",
-}, []string{"id", "name"})
-
-var nodeOnlineLastAt = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_online_last_at",
-	Help: "Last time the node was online",
-}, []string{"id", "name"})
-
-var nodeStatusCreatedAt = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_status_created_at",
-	Help: "Time the node monitoring record was created",
-}, []string{"id", "name"})
-
-var nodeStatusUpdatedAt = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_status_updated_at",
-	Help: "Last time the node status was updated",
-}, []string{"id", "name"})
-
-var nodeIPCategory = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_ip_category",
-	Help: "IP category of the node",
-}, []string{"id", "name", "category"})
-
-var nodeLocation = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_location",
-	Help: "Location of the node",
-}, []string{"id", "name", "location"})
+var nodeCount = newGauge(Metric{
+	Name:   "myst_node_count",
+	Help:   "Total number of nodes",
+	Source: SourceNodes,
+})
+
+var nodeBandwidth = newGaugeVec(Metric{
+	Name:        "myst_node_bandwidth",
+	Help:        "Internet bandwidth of the node",
+	DisplayUnit: "mbit/s",
+	Labels:      []string{"id", "name"},
+	Source:      SourceTotals,
+})
+
+var nodeTraffic = newGaugeVec(Metric{
+	Name:        "myst_node_traffic",
+	Help:        "Traffic transferred by the node over the last 30 days",
+	DisplayUnit: "gigabytes",
+	Labels:      []string{"id", "name"},
+	Source:      SourceTotals,
+	Scheme:      SchemeV1,
+})
+
+var nodeUserID = newGaugeVec(Metric{
+	Name:   "myst_node_user_id",
+	Help:   "User ID of user of the node",
+	Labels: []string{"id", "name", "user_id"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeTermsVersion = newGaugeVec(Metric{
+	Name:   "myst_node_terms_version",
+	Help:   "Terms version of the node",
+	Labels: []string{"id", "name", "version"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeTermsAcceptedAt = newGaugeVec(Metric{
+	Name:        "myst_node_terms_accepted_at",
+	Help:        "Last time terms were accepted by node",
+	DisplayUnit: "unixtime",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNodes,
+	Scheme:      SchemeV1,
+})
+
+var nodeLocalIP = newGaugeVec(Metric{
+	Name:   "myst_node_local_ip",
+	Help:   "Local ip address of the node",
+	Labels: []string{"id", "name", "ip"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeExternalIP = newGaugeVec(Metric{
+	Name:   "myst_node_external_ip",
+	Help:   "External ip address of the node",
+	Labels: []string{"id", "name", "ip"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeISP = newGaugeVec(Metric{
+	Name:   "myst_node_isp",
+	Help:   "Internet Service Provider of the node",
+	Labels: []string{"id", "name", "isp"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeOS = newGaugeVec(Metric{
+	Name:   "myst_node_os",
+	Help:   "Operating system the node is running on",
+	Labels: []string{"id", "name", "os"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeArch = newGaugeVec(Metric{
+	Name:   "myst_node_arch",
+	Help:   "System architecture of the node",
+	Labels: []string{"id", "name", "arch"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeVersion = newGaugeVec(Metric{
+	Name:   "myst_node_version",
+	Help:   "Myst version the node is running on",
+	Labels: []string{"id", "name", "version"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeVendor = newGaugeVec(Metric{
+	Name:   "myst_node_vendor",
+	Help:   "Vendor of the node",
+	Labels: []string{"id", "name", "vendor"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeMalicious = newGaugeVec(Metric{
+	Name:   "myst_node_malicious",
+	Help:   "Whether the node is tagged a malicious",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+})
+
+var nodeAvailableAt = newGaugeVec(Metric{
+	Name:        "myst_node_available_at",
+	Help:        "Last time the node was available",
+	DisplayUnit: "unixtime",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNodes,
+	Scheme:      SchemeV1,
+})
+
+var nodeCreatedAt = newGaugeVec(Metric{
+	Name:        "myst_node_created_at",
+	Help:        "Time the node was created",
+	DisplayUnit: "unixtime",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNodes,
+	Scheme:      SchemeV1,
+})
+
+var nodeUpdatedAt = newGaugeVec(Metric{
+	Name:        "myst_node_updated_at",
+	Help:        "Last time the node was updated",
+	DisplayUnit: "unixtime",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNodes,
+	Scheme:      SchemeV1,
+})
+
+var nodeDeleted = newGaugeVec(Metric{
+	Name:   "myst_node_deleted",
+	Help:   "Whether the node is deleted",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+})
+
+var nodeLauncherVersion = newGaugeVec(Metric{
+	Name:   "myst_node_launcher_version",
+	Help:   "Launcher version the node is running on",
+	Labels: []string{"id", "name", "version"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeIPTagged = newGaugeVec(Metric{
+	Name:   "myst_node_ip_tagged",
+	Help:   "Whether the node is ip tagged",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+})
+
+var nodeMonitoringFailed = newGaugeVec(Metric{
+	Name:   "myst_node_monitoring_failed",
+	Help:   "Whether monitoring on the node failed",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+})
+
+var nodeMonitoringFailedLastAt = newGaugeVec(Metric{
+	Name:        "myst_node_monitoring_failed_last_at",
+	Help:        "Last time monitoring failed on node",
+	DisplayUnit: "unixtime",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNodes,
+	Scheme:      SchemeV1,
+})
+
+var nodeOnline = newGaugeVec(Metric{
+	Name:   "myst_node_online",
+	Help:   "Whether the node is online",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+})
+
+var nodeOnlineLastAt = newGaugeVec(Metric{
+	Name:        "myst_node_online_last_at",
+	Help:        "Last time the node was online",
+	DisplayUnit: "unixtime",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNodes,
+	Scheme:      SchemeV1,
+})
+
+var nodeStatusCreatedAt = newGaugeVec(Metric{
+	Name:        "myst_node_status_created_at",
+	Help:        "Time the node monitoring record was created",
+	DisplayUnit: "unixtime",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNodes,
+	Scheme:      SchemeV1,
+})
+
+var nodeStatusUpdatedAt = newGaugeVec(Metric{
+	Name:        "myst_node_status_updated_at",
+	Help:        "Last time the node status was updated",
+	DisplayUnit: "unixtime",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNodes,
+	Scheme:      SchemeV1,
+})
+
+var nodeIPCategory = newGaugeVec(Metric{
+	Name:   "myst_node_ip_category",
+	Help:   "IP category of the node, 1 for the current category",
+	Labels: []string{"id", "name", "category"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeLocation = newGaugeVec(Metric{
+	Name:   "myst_node_location",
+	Help:   "Location of the node",
+	Labels: []string{"id", "name", "location"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeQuality = newGaugeVec(Metric{
+	Name:   "myst_node_quality",
+	Help:   "Quality score assigned to the node",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+})
+
+var nodeService = newGaugeVec(Metric{
+	Name:   "myst_node_service",
+	Help:   "Whether a service on the node is running",
+	Labels: []string{"id", "name", "service"},
+	Source: SourceNodes,
+})
+
+var nodeMonitoringStatus = newGaugeVec(Metric{
+	Name:   "myst_node_monitoring_status",
+	Help:   "Monitoring status of the node, 1 for the current status",
+	Labels: []string{"id", "name", "status"},
+	Source: SourceNodes,
+	Scheme: SchemeV1,
+})
+
+var nodeEarnings = newGaugeVec(Metric{
+	Name:        "myst_node_earnings",
+	Help:        "Earnings by node and service over the last 30 days",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name", "service"},
+	Source:      SourceNodes,
+})
 
-var nodeQuality = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_quality",
-	Help: "Quality score assigned to the node",
-}, []string{"id", "name"})
+var nodeSessions = newGaugeVec(Metric{
+	Name:   "myst_node_sessions",
+	Help:   "Number of sessions of the node by service and country over the last 30 days",
+	Labels: []string{"id", "name", "service", "country", "continent", "country_name"},
+	Source: SourceSessions,
+})
 
-var nodeService = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_service",
-	Help: "Whether a service on the node is running",
-}, []string{"id", "name", "service"})
+var nodeSessionEarnings = newGaugeVec(Metric{
+	Name:        "myst_node_session_earnings",
+	Help:        "Earnings by node, service and country generated from session log",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name", "service", "country", "continent", "country_name"},
+	Source:      SourceSessions,
+})
 
-var nodeMonitoringStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_monitoring_status",
-	Help: "Monitoring status of the node",
-}, []string{"id", "name", "status"})
+var nodeSessionTraffic = newGaugeVec(Metric{
+	Name:        "myst_node_session_traffic",
+	Help:        "Traffic served by node by service and country, generated from session log",
+	DisplayUnit: "gigabytes",
+	Labels:      []string{"id", "name", "service", "country", "continent", "country_name"},
+	Source:      SourceSessions,
+	Scheme:      SchemeV1,
+})
+
+var nodeSessionDurations = newGaugeVec(Metric{
+	Name:        "myst_node_session_durations",
+	Help:        "Total duration of sessions of the node by service and country over the last 30 days",
+	DisplayUnit: "seconds",
+	Labels:      []string{"id", "name", "service", "country", "continent", "country_name"},
+	Source:      SourceSessions,
+	Scheme:      SchemeV1,
+})
+
+var nodeLifetimeEarnings = newGaugeVec(Metric{
+	Name:        "myst_node_earnings_lifetime",
+	Help:        "Total lifetime earnings by node",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNode,
+})
+
+var nodeSettledEarnings = newGaugeVec(Metric{
+	Name:        "myst_node_earnings_settled",
+	Help:        "Total settled earnings by node",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNode,
+})
 
-var nodeEarnings = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_earnings",
-	Help: "Earnings by node and service over the last 30 days",
-}, []string{"id", "name", "service"})
+var nodeUnsettledEarnings = newGaugeVec(Metric{
+	Name:        "myst_node_earnings_unsettled",
+	Help:        "Unsettled earnings by node",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNode,
+})
 
-var nodeSessions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_sessions",
-	Help: "Number of sessions of the node by service and country over the last 30 days",
-}, []string{"id", "name", "service", "country"})
+var nodeSettlements = newCounterVec(Metric{
+	Name:   "myst_node_settlements_total",
+	Help:   "Number of settlements detected by node",
+	Labels: []string{"id", "name"},
+	Source: SourceNode,
+})
 
-var nodeSessionEarnings = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_session_earnings",
-	Help: "Earnings by node, service and country generated from session log",
-}, []string{"id", "name", "service", "country"})
+var nodeSettledAmount = newCounterVec(Metric{
+	Name:        "myst_node_settled_amount_total",
+	Help:        "Amount of MYST settled in detected settlements by node",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNode,
+})
 
-var nodeSessionTraffic = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_session_traffic",
-	Help: "Traffic served by node by service and country, generated from session log",
-}, []string{"id", "name", "service", "country"})
+var nodeLastSettlementAt = newGaugeVec(Metric{
+	Name:        "myst_node_last_settlement_at",
+	Help:        "Last time a settlement was detected by node",
+	DisplayUnit: "unixtime",
+	Labels:      []string{"id", "name"},
+	Source:      SourceNode,
+	Scheme:      SchemeV1,
+})
 
-var nodeSessionDurations = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_session_durations",
-	Help: "Total duration of sessions of the node by service and country over the last 30 days",
-}, []string{"id", "name", "service", "country"})
+var nodeUnsettledOverdue = newGaugeVec(Metric{
+	Name:   "myst_node_unsettled_overdue",
+	Help:   "Whether the unsettled earnings of the node exceeded the threshold for too long",
+	Labels: []string{"id", "name"},
+	Source: SourceNode,
+})
 
-var nodeLifetimeEarnings = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_earnings_lifetime",
-	Help: "Total lifetime earnings by node",
-}, []string{"id", "name"})
+var nodeEarningsToday = newGaugeVec(Metric{
+	Name:        "myst_node_earnings_today",
+	Help:        "Earnings by node on the current day, derived from the earnings ledger",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name"},
+	Source:      SourceLedger,
+})
 
-var nodeSettledEarnings = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_earnings_settled",
-	Help: "Total settled earnings by node",
-}, []string{"id", "name"})
+var nodeEarningsYesterday = newGaugeVec(Metric{
+	Name:        "myst_node_earnings_yesterday",
+	Help:        "Earnings by node on the previous day, derived from the earnings ledger",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name"},
+	Source:      SourceLedger,
+})
 
-var nodeUnsettledEarnings = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_node_earnings_unsettled",
-	Help: "Unsettled earnings by node",
-}, []string{"id", "name"})
+var globalNodes = newGauge(Metric{
+	Name:   "myst_global_nodes",
+	Help:   "Global node count",
+	Source: SourceGlobalStats,
+})
 
-var rewardPoints = prometheus.NewGauge(prometheus.GaugeOpts{
-	Name: "myst_reward_points",
-	Help: "Collected reward points",
+var globalTraffic = newOptionalGauge(Metric{
+	Name:        "myst_global_traffic",
+	Help:        "Global traffic in bytes",
+	DisplayUnit: "bytes",
+	Source:      SourceGlobalStats,
+	Scheme:      SchemeV1,
 })
 
-var rewardTraffic = prometheus.NewGauge(prometheus.GaugeOpts{
-	Name: "myst_reward_traffic",
-	Help: "Daily traffic accounted for in the reward program",
+var globalCountries = newGauge(Metric{
+	Name:   "myst_global_countries",
+	Help:   "Global countries",
+	Source: SourceGlobalStats,
 })
 
-var rewardStake = prometheus.NewGauge(prometheus.GaugeOpts{
-	Name: "myst_reward_stake",
-	Help: "Staked MYST token in the reward program wallet",
+var globalStat = newGaugeVec(Metric{
+	Name:   "myst_global_stat",
+	Help:   "Numeric value of an additional global stats category",
+	Labels: []string{"category", "key"},
+	Source: SourceGlobalStats,
 })
 
-var rewardUptime = prometheus.NewGauge(prometheus.GaugeOpts{
-	Name: "myst_reward_uptime",
-	Help: "Uptime for the reward program",
+var mystPrice = newGaugeVec(Metric{
+	Name:        "myst_token_price",
+	Help:        "Current price of the MYST token",
+	DisplayUnit: "currency",
+	Labels:      []string{"currency", "source"},
+	Source:      SourcePrices,
 })
 
-var rewardPointsTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-	Name: "myst_reward_points_total",
-	Help: "Sum of all participants collected reward points",
+var mystPriceChange24h = newGaugeVec(Metric{
+	Name:        "myst_token_price_change_24h",
+	Help:        "Price change of the MYST token over the last 24 hours in percent",
+	DisplayUnit: "percent",
+	Labels:      []string{"currency", "source"},
+	Source:      SourcePrices,
 })
 
-var rewardParticipants = prometheus.NewGauge(prometheus.GaugeOpts{
-	Name: "myst_reward_participants",
-	Help: "Total participants in the reward program",
+var mystMarketCap = newGaugeVec(Metric{
+	Name:        "myst_token_market_cap",
+	Help:        "Market capitalization of the MYST token",
+	DisplayUnit: "currency",
+	Labels:      []string{"currency", "source"},
+	Source:      SourcePrices,
 })
 
-var globalNodes = prometheus.NewGauge(prometheus.GaugeOpts{
-	Name: "myst_global_nodes",
-	Help: "Global node count",
+var mystVolume24h = newGaugeVec(Metric{
+	Name:        "myst_token_volume_24h",
+	Help:        "Trading volume of the MYST token over the last 24 hours",
+	DisplayUnit: "currency",
+	Labels:      []string{"currency", "source"},
+	Source:      SourcePrices,
 })
 
-var globalTraffic = prometheus.NewGauge(prometheus.GaugeOpts{
-	Name: "myst_global_traffic",
-	Help: "Global traffic",
+var mystPriceStale = newGauge(Metric{
+	Name:   "myst_token_price_stale",
+	Help:   "Whether every price source failed and the last known prices are exported",
+	Source: SourcePrices,
 })
 
-var globalCountries = prometheus.NewGauge(prometheus.GaugeOpts{
-	Name: "myst_global_countries",
-	Help: "Global countries",
+var mystPriceUpdatedAt = newOptionalGauge(Metric{
+	Name:        "myst_token_price_updated_at",
+	Help:        "Last time the MYST token prices were updated",
+	DisplayUnit: "unixtime",
+	Source:      SourcePrices,
+	Scheme:      SchemeV1,
 })
 
-var mystPrice = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-	Name: "myst_token_price",
-	Help: "Current price of the MYST token",
-}, []string{"currency"})
+var exporterLastCycle = newOptionalGauge(Metric{
+	Name:   "myst_exporter_last_cycle_timestamp_seconds",
+	Help:   "Unix time the nodes were last monitored successfully",
+	Unit:   "seconds",
+	Source: SourceNodes,
+})
 
 func init() {
 	registry.MustRegister(nodeCount, nodeBandwidth, nodeTraffic, nodeUserID, nodeTermsVersion, nodeTermsAcceptedAt,
@@ -265,9 +461,11 @@ func init() {
 		nodeMonitoringFailed, nodeMonitoringFailedLastAt, nodeOnline, nodeOnlineLastAt, nodeStatusCreatedAt,
 		nodeStatusUpdatedAt, nodeIPCategory, nodeLocation, nodeQuality, nodeService, nodeMonitoringStatus,
 		nodeEarnings, nodeSessions, nodeSessionEarnings, nodeSessionTraffic, nodeSessionDurations,
-		nodeLifetimeEarnings, nodeSettledEarnings, nodeUnsettledEarnings, rewardPoints, rewardTraffic,
-		rewardStake, rewardUptime, rewardPointsTotal, rewardParticipants, globalNodes, globalTraffic,
-		globalCountries, mystPrice)
+		nodeLifetimeEarnings, nodeSettledEarnings, nodeUnsettledEarnings, nodeSettlements,
+		nodeSettledAmount, nodeLastSettlementAt, nodeUnsettledOverdue, nodeEarningsToday,
+		nodeEarningsYesterday, globalNodes,
+		globalCountries, globalStat, mystPrice, mystPriceChange24h,
+		mystMarketCap, mystVolume24h, mystPriceStale)
 }
 
 func NodeCount(n int) {
@@ -280,40 +478,88 @@ func NodeSessions(id string, name string, sessions []node.Session) {
 		country string
 	}
 
+	top := topCountries(sessions, config.CountryTopN)
+	fleet := config.CountryAggregation == config.FleetAggregation
+
 	sessionCount := make(map[filter]int)
 	traffic := make(map[filter]float64)
 	durations := make(map[filter]time.Duration)
 	earnings := make(map[filter]float64)
 
 	for _, session := range sessions {
-		f := filter{
-			service: session.ServiceType,
-			country: session.ConsumerCountry,
+		f := filter{service: session.ServiceType}
+		// countries are only exported fleet-wide in fleet aggregation
+		if !fleet {
+			f.country = foldCountry(session.ConsumerCountry, top)
 		}
 
 		sessionCount[f]++
-		traffic[f] += float64(session.Transferred) * math.Pow(10, -9) // convert bytes to GB
+		traffic[f] += float64(session.Transferred)
 		durations[f] += session.Duration
 		earnings[f] += session.Earning
 	}
 
+	// folded countries change between cycles, so stale series are removed
+	for _, gauge := range []*prometheus.GaugeVec{nodeSessions, nodeSessionTraffic, nodeSessionDurations,
+		nodeSessionsTrafficBytes, nodeSessionsDurationSeconds, nodeSessionEarnings, nodeSessionEarningsFiat} {
+		gauge.DeletePartialMatch(prometheus.Labels{"id": id})
+	}
+
 	for f, total := range sessionCount {
-		nodeSessions.WithLabelValues(id, name, f.service, f.country).Set(float64(total))
-		nodeSessionTraffic.WithLabelValues(id, name, f.service, f.country).Set(traffic[f])
-		nodeSessionDurations.WithLabelValues(id, name, f.service, f.country).Set(float64(durations[f].Seconds()))
-		nodeSessionEarnings.WithLabelValues(id, name, f.service, f.country).Set(earnings[f])
+		labels := append([]string{id, name, f.service}, countryLabels(f.country)...)
+		nodeSessions.WithLabelValues(labels...).Set(float64(total))
+		if config.MetricsV1 {
+			nodeSessionTraffic.WithLabelValues(labels...).Set(traffic[f] * math.Pow(10, -9)) // convert bytes to GB
+			nodeSessionDurations.WithLabelValues(labels...).Set(float64(durations[f].Seconds()))
+		}
+		if config.MetricsV2 {
+			nodeSessionsTrafficBytes.WithLabelValues(labels...).Set(traffic[f])
+			nodeSessionsDurationSeconds.WithLabelValues(labels...).Set(durations[f].Seconds())
+		}
+		nodeSessionEarnings.WithLabelValues(labels...).Set(earnings[f])
+		setFiat(nodeSessionEarningsFiat, earnings[f], labels...)
 	}
 }
 
 func NodeMetrics(node node.Node) {
+	if config.MetricsV1 {
+		nodeMetricsV1(node)
+	}
+	if config.MetricsV2 {
+		nodeMetricsV2(node)
+	}
+
+	now := time.Now()
+	if node.Deleted {
+		ipCategories.delete(node.Identity)
+		monitoringStatuses.delete(node.Identity)
+	} else {
+		ipCategories.set(node.Identity, node.Name, node.NodeStatus.IPCategory, now)
+		monitoringStatuses.set(node.Identity, node.Name, node.MonitoringStatus, now)
+	}
+	nodeIPTagged.WithLabelValues(node.Identity, node.Name).Set(boolToFloat(node.IPTagged))
+	nodeMalicious.WithLabelValues(node.Identity, node.Name).Set(boolToFloat(node.Malicious))
+	nodeDeleted.WithLabelValues(node.Identity, node.Name).Set(boolToFloat(node.Deleted))
+
+	nodeMonitoringFailed.WithLabelValues(node.Identity, node.Name).Set(boolToFloat(node.NodeStatus.MonitoringFailed))
+	nodeOnline.WithLabelValues(node.Identity, node.Name).Set(boolToFloat(node.NodeStatus.Online))
+	nodeQuality.WithLabelValues(node.Identity, node.Name).Set(node.NodeStatus.Quality)
+
+	for _, earnings := range node.Earnings {
+		nodeEarnings.WithLabelValues(node.Identity, node.Name, earnings.Service).Set(earnings.EtherAmount)
+		setFiat(nodeEarningsFiat, earnings.EtherAmount, node.Identity, node.Name, earnings.Service)
+		nodeService.WithLabelValues(node.Identity, node.Name, earnings.Service).Set(
+			boolToFloat(slices.Contains(node.NodeStatus.ServiceTypes, earnings.Service)))
+	}
+}
+
+func nodeMetricsV1(node node.Node) {
 	nodeUserID.WithLabelValues(node.Identity, node.Name, node.UserID).Set(1)
 	nodeTermsVersion.WithLabelValues(node.Identity, node.Name, node.TermsVersion).Set(1)
-	nodeTermsAcceptedAt.WithLabelValues(node.Identity, node.Name).Set(float64(node.TermsAcceptedAt.Unix()))
+	setTimestamp(nodeTermsAcceptedAt, node.TermsAcceptedAt, node.Identity, node.Name)
 
 	nodeLocalIP.WithLabelValues(node.Identity, node.Name, node.LocalIP).Set(1)
 	nodeExternalIP.WithLabelValues(node.Identity, node.Name, node.ExternalIP).Set(1)
-	nodeIPCategory.WithLabelValues(node.Identity, node.Name, node.NodeStatus.IPCategory).Set(1)
-	nodeIPTagged.WithLabelValues(node.Identity, node.Name).Set(boolToFloat(node.IPTagged))
 	nodeISP.WithLabelValues(node.Identity, node.Name, node.ISP).Set(1)
 	nodeLocation.WithLabelValues(node.Identity, node.Name, node.NodeStatus.Location).Set(1)
 
@@ -322,64 +568,124 @@ func NodeMetrics(node node.Node) {
 	nodeVersion.WithLabelValues(node.Identity, node.Name, node.Version).Set(1)
 	nodeLauncherVersion.WithLabelValues(node.Identity, node.Name, node.LauncherVersion).Set(1)
 	nodeVendor.WithLabelValues(node.Identity, node.Name, node.Vendor).Set(1)
-	nodeMalicious.WithLabelValues(node.Identity, node.Name).Set(boolToFloat(node.Malicious))
-
-	nodeAvailableAt.WithLabelValues(node.Identity, node.Name).Set(float64(node.AvailableAt.Unix()))
-	nodeCreatedAt.WithLabelValues(node.Identity, node.Name).Set(float64(node.CreatedAt.Unix()))
-	nodeUpdatedAt.WithLabelValues(node.Identity, node.Name).Set(float64(node.UpdatedAt.Unix()))
-	nodeDeleted.WithLabelValues(node.Identity, node.Name).Set(boolToFloat(node.Deleted))
 
-	nodeMonitoringStatus.WithLabelValues(node.Identity, node.Name, node.MonitoringStatus).Set(1)
-	nodeMonitoringFailed.WithLabelValues(node.Identity, node.Name).Set(boolToFloat(node.NodeStatus.MonitoringFailed))
-	nodeMonitoringFailedLastAt.WithLabelValues(node.Identity, node.Name).Set(float64(node.NodeStatus.MonitoringFailedLastAt.Unix()))
-	nodeOnline.WithLabelValues(node.Identity, node.Name).Set(boolToFloat(node.NodeStatus.Online))
-	nodeOnlineLastAt.WithLabelValues(node.Identity, node.Name).Set(float64(node.NodeStatus.OnlineLastAt.Unix()))
-	nodeStatusCreatedAt.WithLabelValues(node.Identity, node.Name).Set(float64(node.NodeStatus.CreatedAt.Unix()))
-	nodeStatusUpdatedAt.WithLabelValues(node.Identity, node.Name).Set(float64(node.NodeStatus.UpdatedAt.Unix()))
-	nodeQuality.WithLabelValues(node.Identity, node.Name).Set(node.NodeStatus.Quality)
+	setTimestamp(nodeAvailableAt, node.AvailableAt, node.Identity, node.Name)
+	setTimestamp(nodeCreatedAt, &node.CreatedAt, node.Identity, node.Name)
+	setTimestamp(nodeUpdatedAt, &node.UpdatedAt, node.Identity, node.Name)
 
-	for _, earnings := range node.Earnings {
-		nodeEarnings.WithLabelValues(node.Identity, node.Name, earnings.Service).Set(earnings.EtherAmount)
-		nodeService.WithLabelValues(node.Identity, node.Name, earnings.Service).Set(
-			boolToFloat(slices.Contains(node.NodeStatus.ServiceTypes, earnings.Service)))
-	}
+	setTimestamp(nodeMonitoringFailedLastAt, node.NodeStatus.MonitoringFailedLastAt, node.Identity, node.Name)
+	setTimestamp(nodeOnlineLastAt, node.NodeStatus.OnlineLastAt, node.Identity, node.Name)
+	setTimestamp(nodeStatusCreatedAt, &node.NodeStatus.CreatedAt, node.Identity, node.Name)
+	setTimestamp(nodeStatusUpdatedAt, &node.NodeStatus.UpdatedAt, node.Identity, node.Name)
 }
 
 func NodeLifetimeEarnings(id string, name string, earnings node.LifetimeEarnings) {
 	nodeLifetimeEarnings.WithLabelValues(id, name).Set(earnings.Total)
 	nodeSettledEarnings.WithLabelValues(id, name).Set(earnings.Settled)
 	nodeUnsettledEarnings.WithLabelValues(id, name).Set(earnings.Unsettled)
+
+	setFiat(nodeLifetimeEarningsFiat, earnings.Total, id, name)
+	setFiat(nodeSettledEarningsFiat, earnings.Settled, id, name)
+	setFiat(nodeUnsettledEarningsFiat, earnings.Unsettled, id, name)
 }
 
-func NodeTotals(id string, name string, t *totals.Totals) {
-	nodeBandwidth.WithLabelValues(id, name).Set(t.BandwidthTotal)
-	nodeTraffic.WithLabelValues(id, name).Set(t.TrafficTotal * 1024)
+func NodeSettlements(id string, name string, settled float64, at time.Time, overdue bool) {
+	if settled > 0 {
+		nodeSettlements.WithLabelValues(id, name).Inc()
+		nodeSettledAmount.WithLabelValues(id, name).Add(settled)
+		NodeLastSettlement(id, name, at)
+	} else {
+		// initialize the counters, so increases are visible from the first settlement on
+		nodeSettlements.WithLabelValues(id, name)
+		nodeSettledAmount.WithLabelValues(id, name)
+	}
+	nodeUnsettledOverdue.WithLabelValues(id, name).Set(boolToFloat(overdue))
 }
 
-func RewardProgram(ranks []rewards.User, points *rewards.Points, stats *rewards.Stats) {
-	rewardPoints.Set(points.Total)
-	rewardTraffic.Set(stats.Data[0]) // TODO: unsafe...
-	rewardStake.Set(stats.Myst[0])
-	rewardUptime.Set(stats.Uptime[0])
+// NodeLastSettlement exports the time of the last settlement of the node.
+func NodeLastSettlement(id string, name string, at time.Time) {
+	if config.MetricsV1 {
+		nodeLastSettlementAt.WithLabelValues(id, name).Set(float64(at.Unix()))
+	}
+	if config.MetricsV2 {
+		nodeLastSettlementTimestamp.WithLabelValues(id, name).Set(float64(at.Unix()))
+	}
+}
+
+func NodeDailyEarnings(id string, name string, today float64, yesterday float64) {
+	nodeEarningsToday.WithLabelValues(id, name).Set(today)
+	nodeEarningsYesterday.WithLabelValues(id, name).Set(yesterday)
+}
 
-	var totalPoints float64
-	for _, p := range ranks {
-		totalPoints += p.PointsTotal
+func NodeTotals(id string, name string, t *totals.Totals) {
+	nodeBandwidth.WithLabelValues(id, name).Set(t.BandwidthTotal)
+	if config.MetricsV1 {
+		nodeTraffic.WithLabelValues(id, name).Set(t.TrafficTotal * 1024)
+	}
+	if config.MetricsV2 {
+		nodeTrafficBytes.WithLabelValues(id, name).Set(t.TrafficTotal * bytesPerTotalsTraffic)
 	}
-	rewardPointsTotal.Set(totalPoints)
-	rewardParticipants.Set(float64(len(ranks)))
 }
 
 func GlobalStats(stats *stats.Global) {
 	globalNodes.Set(float64(stats.TotalNodes))
-	globalTraffic.Set(float64(stats.TotalTraffic))
+	if config.MetricsV1 {
+		globalTraffic.Set(stats.TotalTraffic)
+	}
+	if config.MetricsV2 {
+		globalTrafficBytes.Set(stats.TotalTraffic)
+	}
 	globalCountries.Set(float64(stats.TotalCountries))
 }
 
-func MystPrices(prices map[string]float64) {
-	for currency, price := range prices {
-		mystPrice.WithLabelValues(currency).Set(price)
+func GlobalCategoryStats(category string, values map[string]float64) {
+	globalStat.DeletePartialMatch(prometheus.Labels{"category": category})
+	for key, value := range values {
+		globalStat.WithLabelValues(category, key).Set(value)
+	}
+}
+
+func MystPrices(quote *prices.Quote) {
+	mystPrice.Reset()
+	for currency, price := range quote.Prices {
+		mystPrice.WithLabelValues(currency, quote.Source).Set(price)
+	}
+	latestPrices = quote.Prices
+
+	mystPriceChange24h.Reset()
+	mystMarketCap.Reset()
+	mystVolume24h.Reset()
+	for currency, market := range quote.Market {
+		mystPriceChange24h.WithLabelValues(currency, quote.MarketSource).Set(market.Change24h)
+		mystMarketCap.WithLabelValues(currency, quote.MarketSource).Set(market.MarketCap)
+		mystVolume24h.WithLabelValues(currency, quote.MarketSource).Set(market.Volume24h)
+	}
+	mystPriceStale.Set(0)
+	if config.MetricsV1 {
+		mystPriceUpdatedAt.Set(float64(time.Now().Unix()))
+	}
+	if config.MetricsV2 {
+		mystPriceUpdatedTimestamp.Set(float64(time.Now().Unix()))
+	}
+}
+
+// MonitorCycle records a successful monitor cycle at t.
+func MonitorCycle(t time.Time) {
+	exporterLastCycle.Set(float64(t.Unix()))
+}
+
+func MystPricesStale() {
+	mystPriceStale.Set(1)
+}
+
+// setTimestamp exports t as unix time. Missing or zero times would be exported
+// as year 1, so the series is deleted instead.
+func setTimestamp(gauge *prometheus.GaugeVec, t *time.Time, labels ...string) {
+	if t == nil || t.IsZero() {
+		gauge.DeleteLabelValues(labels...)
+		return
 	}
+	gauge.WithLabelValues(labels...).Set(float64(t.Unix()))
 }
 
 // https://github.com/golang/go/issues/64825
@@ -0,0 +1,28 @@
+package metrics
+
+var pushQueueLength = newGaugeVec(Metric{
+	Name:   "myst_exporter_push_queue_length",
+	Help:   "Number of metric snapshots waiting to be pushed by target",
+	Labels: []string{"target"},
+	Source: SourceExporter,
+})
+
+var pushErrors = newCounterVec(Metric{
+	Name:   "myst_exporter_push_errors_total",
+	Help:   "Number of failed pushes by target",
+	Labels: []string{"target"},
+	Source: SourceExporter,
+})
+
+func init() {
+	registry.MustRegister(pushQueueLength, pushErrors)
+}
+
+// PushQueue exports the number of snapshots waiting to be pushed to the target.
+func PushQueue(target string, length int) {
+	pushQueueLength.WithLabelValues(target).Set(float64(length))
+}
+
+func PushFailed(target string) {
+	pushErrors.WithLabelValues(target).Inc()
+}
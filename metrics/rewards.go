@@ -0,0 +1,275 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/rewards"
+)
+
+var rewardPoints = newGauge(Metric{
+	Name:   "myst_reward_points",
+	Help:   "Collected reward points",
+	Unit:   "points",
+	Source: SourceRewardPoints,
+})
+
+var rewardTraffic = newGauge(Metric{
+	Name:   "myst_reward_traffic",
+	Help:   "Daily traffic accounted for in the reward program",
+	Source: SourceRewardStats,
+})
+
+var rewardStake = newGauge(Metric{
+	Name:        "myst_reward_stake",
+	Help:        "Staked MYST token in the reward program wallet",
+	DisplayUnit: "MYST",
+	Source:      SourceRewardStats,
+})
+
+var rewardUptime = newGauge(Metric{
+	Name:   "myst_reward_uptime",
+	Help:   "Uptime for the reward program",
+	Source: SourceRewardStats,
+})
+
+var rewardNodes = newGauge(Metric{
+	Name:   "myst_reward_nodes",
+	Help:   "Nodes accounted for in the reward program",
+	Source: SourceRewardStats,
+})
+
+var rewardTrafficHistory = newGaugeVec(Metric{
+	Name:   "myst_reward_traffic_history",
+	Help:   "Daily traffic accounted for in the reward program by days ago",
+	Labels: []string{"days_ago"},
+	Source: SourceRewardStats,
+})
+
+var rewardStakeHistory = newGaugeVec(Metric{
+	Name:        "myst_reward_stake_history",
+	Help:        "Staked MYST token in the reward program wallet by days ago",
+	DisplayUnit: "MYST",
+	Labels:      []string{"days_ago"},
+	Source:      SourceRewardStats,
+})
+
+var rewardUptimeHistory = newGaugeVec(Metric{
+	Name:   "myst_reward_uptime_history",
+	Help:   "Uptime for the reward program by days ago",
+	Labels: []string{"days_ago"},
+	Source: SourceRewardStats,
+})
+
+var rewardNodesHistory = newGaugeVec(Metric{
+	Name:   "myst_reward_nodes_history",
+	Help:   "Nodes accounted for in the reward program by days ago",
+	Labels: []string{"days_ago"},
+	Source: SourceRewardStats,
+})
+
+var rewardPointsTotal = newGauge(Metric{
+	Name:        "myst_reward_points_total",
+	Help:        "Sum of all participants collected reward points",
+	DisplayUnit: "points",
+	Source:      SourceRewardRanks,
+})
+
+var rewardParticipants = newGauge(Metric{
+	Name:   "myst_reward_participants",
+	Help:   "Total participants in the reward program",
+	Source: SourceRewardRanks,
+})
+
+var rewardRank = newGauge(Metric{
+	Name:   "myst_reward_rank",
+	Help:   "Own rank position in the reward program, 0 if not ranked",
+	Source: SourceRewardRanks,
+})
+
+var rewardPointsShare = newGauge(Metric{
+	Name:        "myst_reward_points_share",
+	Help:        "Own share of all participants collected reward points",
+	DisplayUnit: "ratio",
+	Source:      SourceRewardRanks,
+})
+
+var rewardPointsGapAbove = newOptionalGauge(Metric{
+	Name:        "myst_reward_points_gap_above",
+	Help:        "Reward points missing to reach the rank above",
+	DisplayUnit: "points",
+	Source:      SourceRewardRanks,
+})
+
+var rewardPointsGapBelow = newOptionalGauge(Metric{
+	Name:        "myst_reward_points_gap_below",
+	Help:        "Reward points ahead of the rank below",
+	DisplayUnit: "points",
+	Source:      SourceRewardRanks,
+})
+
+var leaderboardPoints = newGaugeVec(Metric{
+	Name:   "myst_reward_leaderboard_points",
+	Help:   "Total reward points of the top participants",
+	Unit:   "points",
+	Labels: []string{"address", "rank"},
+	Source: SourceRewardRanks,
+})
+
+var leaderboardPointsData = newGaugeVec(Metric{
+	Name:        "myst_reward_leaderboard_points_data",
+	Help:        "Data reward points of the top participants",
+	DisplayUnit: "points",
+	Labels:      []string{"address", "rank"},
+	Source:      SourceRewardRanks,
+})
+
+var leaderboardPointsMyst = newGaugeVec(Metric{
+	Name:        "myst_reward_leaderboard_points_myst",
+	Help:        "MYST reward points of the top participants",
+	DisplayUnit: "points",
+	Labels:      []string{"address", "rank"},
+	Source:      SourceRewardRanks,
+})
+
+var leaderboardPointsUptime = newGaugeVec(Metric{
+	Name:        "myst_reward_leaderboard_points_uptime",
+	Help:        "Uptime reward points of the top participants",
+	DisplayUnit: "points",
+	Labels:      []string{"address", "rank"},
+	Source:      SourceRewardRanks,
+})
+
+var leaderboardActiveNodes = newGaugeVec(Metric{
+	Name:   "myst_reward_leaderboard_active_nodes",
+	Help:   "Active nodes of the top participants",
+	Labels: []string{"address", "rank"},
+	Source: SourceRewardRanks,
+})
+
+func init() {
+	registry.MustRegister(rewardPoints, rewardTraffic, rewardStake, rewardUptime, rewardNodes,
+		rewardTrafficHistory, rewardStakeHistory, rewardUptimeHistory, rewardNodesHistory,
+		rewardPointsTotal, rewardParticipants, rewardRank, rewardPointsShare, leaderboardPoints,
+		leaderboardPointsData, leaderboardPointsMyst, leaderboardPointsUptime, leaderboardActiveNodes)
+}
+
+func RewardProgram(ranks []rewards.User, points *rewards.Points, stats *rewards.Stats) {
+	if points != nil {
+		rewardPoints.Set(points.Total)
+	}
+
+	if stats != nil {
+		rewardStats(stats)
+	}
+
+	rewardRanks(ranks)
+}
+
+// rewardStats exports the daily history of the reward program stats. The first
+// element of every list holds the value of the current day.
+func rewardStats(stats *rewards.Stats) {
+	setLatest(rewardTraffic, stats.Data)
+	setLatest(rewardStake, stats.Myst)
+	setLatest(rewardUptime, stats.Uptime)
+	setLatest(rewardNodes, intsToFloats(stats.Nodes))
+
+	setHistory(rewardTrafficHistory, stats.Data)
+	setHistory(rewardStakeHistory, stats.Myst)
+	setHistory(rewardUptimeHistory, stats.Uptime)
+	setHistory(rewardNodesHistory, intsToFloats(stats.Nodes))
+}
+
+func rewardRanks(ranks []rewards.User) {
+	var totalPoints float64
+	for _, p := range ranks {
+		totalPoints += p.PointsTotal
+	}
+	rewardPointsTotal.Set(totalPoints)
+	rewardParticipants.Set(float64(len(ranks)))
+
+	position, user := currentRank(ranks)
+	rewardRank.Set(float64(position))
+	rewardPointsGaps(ranks, position)
+
+	if user == nil || totalPoints == 0 {
+		rewardPointsShare.Set(0)
+		return
+	}
+	rewardPointsShare.Set(user.PointsTotal / totalPoints)
+}
+
+// RewardLeaderboard exports the reward points of the top n participants, none
+// if n is not positive.
+func RewardLeaderboard(ranks []rewards.User, n int) {
+	leaderboardPoints.Reset()
+	leaderboardPointsData.Reset()
+	leaderboardPointsMyst.Reset()
+	leaderboardPointsUptime.Reset()
+	leaderboardActiveNodes.Reset()
+
+	for i, user := range ranks[:max(min(n, len(ranks)), 0)] {
+		rank := strconv.Itoa(i + 1)
+		leaderboardPoints.WithLabelValues(user.Address, rank).Set(user.PointsTotal)
+		leaderboardPointsData.WithLabelValues(user.Address, rank).Set(user.PointsData)
+		leaderboardPointsMyst.WithLabelValues(user.Address, rank).Set(user.PointsMyst)
+		leaderboardPointsUptime.WithLabelValues(user.Address, rank).Set(user.PointsUptime)
+		leaderboardActiveNodes.WithLabelValues(user.Address, rank).Set(float64(user.ActiveNodes))
+	}
+}
+
+// rewardPointsGaps exports the distance in points to the neighbouring ranks of
+// the given one based position. Gaps to ranks that do not exist are omitted.
+func rewardPointsGaps(ranks []rewards.User, position int) {
+	if position == 0 {
+		rewardPointsGapAbove.Clear()
+		rewardPointsGapBelow.Clear()
+		return
+	}
+
+	points := ranks[position-1].PointsTotal
+	if position > 1 {
+		rewardPointsGapAbove.Set(ranks[position-2].PointsTotal - points)
+	} else {
+		rewardPointsGapAbove.Clear()
+	}
+	if position < len(ranks) {
+		rewardPointsGapBelow.Set(points - ranks[position].PointsTotal)
+	} else {
+		rewardPointsGapBelow.Clear()
+	}
+}
+
+// currentRank returns the one based rank position and entry of the current user.
+// The returned position is 0 if the current user is not ranked.
+func currentRank(ranks []rewards.User) (int, *rewards.User) {
+	for i := range ranks {
+		if ranks[i].Current {
+			return i + 1, &ranks[i]
+		}
+	}
+	return 0, nil
+}
+
+func setLatest(gauge prometheus.Gauge, values []float64) {
+	if len(values) == 0 {
+		return
+	}
+	gauge.Set(values[0])
+}
+
+func setHistory(gauge *prometheus.GaugeVec, values []float64) {
+	gauge.Reset()
+	for daysAgo, value := range values {
+		gauge.WithLabelValues(strconv.Itoa(daysAgo)).Set(value)
+	}
+}
+
+func intsToFloats(values []int) []float64 {
+	floats := make([]float64, len(values))
+	for i, v := range values {
+		floats[i] = float64(v)
+	}
+	return floats
+}
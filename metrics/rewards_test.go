@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/rewards"
+)
+
+func TestRewardLeaderboard(t *testing.T) {
+	ranks := []rewards.User{{Address: "0xa", PointsTotal: 3}, {Address: "0xb", PointsTotal: 2}}
+	for _, test := range []struct {
+		n    int
+		want int
+	}{{n: -1, want: 0}, {n: 0, want: 0}, {n: 1, want: 1}, {n: 5, want: 2}} {
+		RewardLeaderboard(ranks, test.n)
+		if got := testutil.CollectAndCount(leaderboardPoints); got != test.want {
+			t.Errorf("got %d leaderboard series for n = %d, want %d", got, test.n, test.want)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package metrics
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+	"github.com/sch8ill/mystprom/config"
+)
+
+var nodeSessionsTotal = newCounterVec(Metric{
+	Name:   "myst_node_observed_sessions_total",
+	Help:   "Number of sessions of the node by service and country observed by the exporter",
+	Labels: []string{"id", "name", "service", "country"},
+	Source: SourceSessions,
+})
+
+var nodeSessionTrafficTotal = newCounterVec(Metric{
+	Name:   "myst_node_observed_session_traffic_bytes_total",
+	Help:   "Traffic served in sessions of the node by service and country observed by the exporter",
+	Unit:   "bytes",
+	Labels: []string{"id", "name", "service", "country"},
+	Source: SourceSessions,
+})
+
+var nodeSessionEarningsTotal = newCounterVec(Metric{
+	Name:        "myst_node_observed_session_earnings_total",
+	Help:        "Earnings of sessions of the node by service and country observed by the exporter",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name", "service", "country"},
+	Source:      SourceSessions,
+})
+
+var nodeSessionDurationTotal = newCounterVec(Metric{
+	Name:   "myst_node_observed_session_duration_seconds_total",
+	Help:   "Duration of sessions of the node by service and country observed by the exporter",
+	Unit:   "seconds",
+	Labels: []string{"id", "name", "service", "country"},
+	Source: SourceSessions,
+})
+
+// the histograms expose classic buckets as well as native buckets to scrapers
+// supporting native histograms.
+const nativeHistogramBucketFactor = 1.1
+
+var nodeSessionDuration = newHistogramVec(Metric{
+	Name:   "myst_node_session_duration_seconds",
+	Help:   "Distribution of the duration of sessions of the node by service",
+	Unit:   "seconds",
+	Labels: []string{"id", "name", "service"},
+	Source: SourceSessions,
+}, prometheus.HistogramOpts{
+	Buckets:                     []float64{10, 30, 60, 300, 900, 1800, 3600, 7200, 21600, 43200, 86400},
+	NativeHistogramBucketFactor: nativeHistogramBucketFactor,
+})
+
+var nodeSessionTransferred = newHistogramVec(Metric{
+	Name:   "myst_node_session_transferred_bytes",
+	Help:   "Distribution of the traffic transferred in sessions of the node by service",
+	Unit:   "bytes",
+	Labels: []string{"id", "name", "service"},
+	Source: SourceSessions,
+}, prometheus.HistogramOpts{
+	Buckets:                     prometheus.ExponentialBuckets(1e5, 10, 7), // 100 KB to 100 GB
+	NativeHistogramBucketFactor: nativeHistogramBucketFactor,
+})
+
+var nodeEarningsPerSession = newHistogramVec(Metric{
+	Name:        "myst_node_earnings_per_session",
+	Help:        "Distribution of the earnings of sessions of the node by service",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name", "service"},
+	Source:      SourceSessions,
+}, prometheus.HistogramOpts{
+	Buckets:                     prometheus.ExponentialBuckets(1e-5, 10, 7), // 0.00001 to 10 MYST
+	NativeHistogramBucketFactor: nativeHistogramBucketFactor,
+})
+
+var nodeRecentSessions = newGaugeVec(Metric{
+	Name:   "myst_node_recent_sessions",
+	Help:   "Number of sessions of the node by service and country started within the window",
+	Labels: []string{"id", "name", "service", "country", "window"},
+	Source: SourceSessions,
+})
+
+var nodeRecentSessionTraffic = newGaugeVec(Metric{
+	Name:   "myst_node_recent_session_traffic_bytes",
+	Help:   "Traffic served in sessions of the node by service and country started within the window",
+	Unit:   "bytes",
+	Labels: []string{"id", "name", "service", "country", "window"},
+	Source: SourceSessions,
+})
+
+var nodeRecentSessionEarnings = newGaugeVec(Metric{
+	Name:        "myst_node_recent_session_earnings",
+	Help:        "Earnings of sessions of the node by service and country started within the window",
+	DisplayUnit: "MYST",
+	Labels:      []string{"id", "name", "service", "country", "window"},
+	Source:      SourceSessions,
+})
+
+var nodeRecentSessionDuration = newGaugeVec(Metric{
+	Name:   "myst_node_recent_session_duration_seconds",
+	Help:   "Duration of sessions of the node by service and country started within the window",
+	Unit:   "seconds",
+	Labels: []string{"id", "name", "service", "country", "window"},
+	Source: SourceSessions,
+})
+
+var fleetSessionsTotal = newCounterVec(Metric{
+	Name:   "myst_fleet_observed_sessions_total",
+	Help:   "Number of sessions of all nodes by service and country observed by the exporter",
+	Labels: []string{"service", "country"},
+	Source: SourceSessions,
+})
+
+var fleetSessionTrafficTotal = newCounterVec(Metric{
+	Name:   "myst_fleet_observed_session_traffic_bytes_total",
+	Help:   "Traffic served in sessions of all nodes by service and country observed by the exporter",
+	Unit:   "bytes",
+	Labels: []string{"service", "country"},
+	Source: SourceSessions,
+})
+
+var fleetSessionEarningsTotal = newCounterVec(Metric{
+	Name:        "myst_fleet_observed_session_earnings_total",
+	Help:        "Earnings of sessions of all nodes by service and country observed by the exporter",
+	DisplayUnit: "MYST",
+	Labels:      []string{"service", "country"},
+	Source:      SourceSessions,
+})
+
+var fleetSessionDurationTotal = newCounterVec(Metric{
+	Name:   "myst_fleet_observed_session_duration_seconds_total",
+	Help:   "Duration of sessions of all nodes by service and country observed by the exporter",
+	Unit:   "seconds",
+	Labels: []string{"service", "country"},
+	Source: SourceSessions,
+})
+
+var fleetRecentSessions = newGaugeVec(Metric{
+	Name:   "myst_fleet_recent_sessions",
+	Help:   "Number of sessions of all nodes by service and country started within the window",
+	Labels: []string{"service", "country", "window"},
+	Source: SourceSessions,
+})
+
+var fleetRecentSessionTraffic = newGaugeVec(Metric{
+	Name:   "myst_fleet_recent_session_traffic_bytes",
+	Help:   "Traffic served in sessions of all nodes by service and country started within the window",
+	Unit:   "bytes",
+	Labels: []string{"service", "country", "window"},
+	Source: SourceSessions,
+})
+
+var fleetRecentSessionEarnings = newGaugeVec(Metric{
+	Name:        "myst_fleet_recent_session_earnings",
+	Help:        "Earnings of sessions of all nodes by service and country started within the window",
+	DisplayUnit: "MYST",
+	Labels:      []string{"service", "country", "window"},
+	Source:      SourceSessions,
+})
+
+var fleetRecentSessionDuration = newGaugeVec(Metric{
+	Name:   "myst_fleet_recent_session_duration_seconds",
+	Help:   "Duration of sessions of all nodes by service and country started within the window",
+	Unit:   "seconds",
+	Labels: []string{"service", "country", "window"},
+	Source: SourceSessions,
+})
+
+func init() {
+	registry.MustRegister(nodeSessionsTotal, nodeSessionTrafficTotal, nodeSessionEarningsTotal,
+		nodeSessionDurationTotal, nodeSessionDuration, nodeSessionTransferred, nodeEarningsPerSession,
+		nodeRecentSessions, nodeRecentSessionTraffic, nodeRecentSessionEarnings, nodeRecentSessionDuration,
+		fleetSessionsTotal, fleetSessionTrafficTotal, fleetSessionEarningsTotal, fleetSessionDurationTotal,
+		fleetRecentSessions, fleetRecentSessionTraffic, fleetRecentSessionEarnings, fleetRecentSessionDuration)
+}
+
+// NodeNewSessions accounts sessions that have not been observed before. Each
+// session must only be passed once. The top countries are determined from all
+// sessions of the node returned by the api. In fleet aggregation the counters
+// are exported without countries, see FleetNewSessions.
+func NodeNewSessions(id string, name string, observed []node.Session, all []node.Session) {
+	top := topCountries(all, config.CountryTopN)
+	fleet := config.CountryAggregation == config.FleetAggregation
+
+	for _, session := range observed {
+		var country string
+		if !fleet {
+			country = foldCountry(session.ConsumerCountry, top)
+		}
+
+		labels := []string{id, name, session.ServiceType, country}
+		exemplar := sessionExemplar(id, session)
+		addWithExemplar(nodeSessionsTotal.WithLabelValues(labels...), 1, exemplar)
+		addWithExemplar(nodeSessionTrafficTotal.WithLabelValues(labels...), float64(session.Transferred), exemplar)
+		addWithExemplar(nodeSessionEarningsTotal.WithLabelValues(labels...), session.Earning, exemplar)
+		addWithExemplar(nodeSessionDurationTotal.WithLabelValues(labels...), session.Duration.Seconds(), exemplar)
+
+		nodeSessionDuration.WithLabelValues(id, name, session.ServiceType).Observe(session.Duration.Seconds())
+		nodeSessionTransferred.WithLabelValues(id, name, session.ServiceType).Observe(float64(session.Transferred))
+		nodeEarningsPerSession.WithLabelValues(id, name, session.ServiceType).Observe(session.Earning)
+	}
+}
+
+// FleetNewSessions accounts the sessions observed on all nodes by country
+// without node labels. The top countries are determined from all sessions.
+func FleetNewSessions(observed map[string][]node.Session, all map[string][]node.Session) {
+	top := topCountries(flatten(all), config.CountryTopN)
+
+	for id, sessions := range observed {
+		for _, session := range sessions {
+			labels := []string{session.ServiceType, foldCountry(session.ConsumerCountry, top)}
+			exemplar := sessionExemplar(id, session)
+			addWithExemplar(fleetSessionsTotal.WithLabelValues(labels...), 1, exemplar)
+			addWithExemplar(fleetSessionTrafficTotal.WithLabelValues(labels...), float64(session.Transferred), exemplar)
+			addWithExemplar(fleetSessionEarningsTotal.WithLabelValues(labels...), session.Earning, exemplar)
+			addWithExemplar(fleetSessionDurationTotal.WithLabelValues(labels...), session.Duration.Seconds(), exemplar)
+		}
+	}
+}
+
+// sessionExemplar links a counter increment to the session. It is nil if the
+// labels exceed the exemplar size limit.
+func sessionExemplar(id string, session node.Session) prometheus.Labels {
+	exemplar := prometheus.Labels{
+		"node":       id,
+		"started_at": session.StartedAt.UTC().Format(time.RFC3339),
+		"service":    session.ServiceType,
+	}
+
+	var runes int
+	for name, value := range exemplar {
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+	if runes > prometheus.ExemplarMaxRunes {
+		return nil
+	}
+	return exemplar
+}
+
+func addWithExemplar(counter prometheus.Counter, value float64, exemplar prometheus.Labels) {
+	if exemplar == nil {
+		counter.Add(value)
+		return
+	}
+	counter.(prometheus.ExemplarAdder).AddWithExemplar(value, exemplar)
+}
+
+// windowKey groups the sessions started within a window.
+type windowKey struct {
+	service string
+	country string
+	window  string
+}
+
+type windowTotals struct {
+	sessions int
+	traffic  float64
+	duration time.Duration
+	earnings float64
+}
+
+// sessionWindows sums up the sessions started within each of the rolling windows
+// before now by service and the country returned by country.
+func sessionWindows(sessions []node.Session, windows []config.Window, now time.Time, country func(code string) string) map[windowKey]*windowTotals {
+	totals := make(map[windowKey]*windowTotals)
+	for _, window := range windows {
+		start := now.Add(-window.Duration)
+		for _, session := range sessions {
+			if session.StartedAt.Before(start) {
+				continue
+			}
+
+			key := windowKey{service: session.ServiceType, country: country(session.ConsumerCountry), window: window.Name}
+			t, ok := totals[key]
+			if !ok {
+				t = &windowTotals{}
+				totals[key] = t
+			}
+			t.sessions++
+			t.traffic += float64(session.Transferred)
+			t.duration += session.Duration
+			t.earnings += session.Earning
+		}
+	}
+	return totals
+}
+
+// NodeSessionWindows exports the sessions of the node started within each of the
+// rolling windows before now. In fleet aggregation they are exported without
+// countries, see FleetSessionWindows.
+func NodeSessionWindows(id string, name string, sessions []node.Session, windows []config.Window, now time.Time) {
+	top := topCountries(sessions, config.CountryTopN)
+	fleet := config.CountryAggregation == config.FleetAggregation
+
+	totals := sessionWindows(sessions, windows, now, func(code string) string {
+		if fleet {
+			return ""
+		}
+		return foldCountry(code, top)
+	})
+
+	// windows without sessions and folded countries are removed instead of kept at their last value
+	for _, gauge := range []*prometheus.GaugeVec{nodeRecentSessions, nodeRecentSessionTraffic,
+		nodeRecentSessionEarnings, nodeRecentSessionDuration} {
+		gauge.DeletePartialMatch(prometheus.Labels{"id": id})
+	}
+
+	for k, t := range totals {
+		nodeRecentSessions.WithLabelValues(id, name, k.service, k.country, k.window).Set(float64(t.sessions))
+		nodeRecentSessionTraffic.WithLabelValues(id, name, k.service, k.country, k.window).Set(t.traffic)
+		nodeRecentSessionEarnings.WithLabelValues(id, name, k.service, k.country, k.window).Set(t.earnings)
+		nodeRecentSessionDuration.WithLabelValues(id, name, k.service, k.country, k.window).Set(t.duration.Seconds())
+	}
+}
+
+// FleetSessionWindows exports the sessions of all nodes started within each of
+// the rolling windows before now by country without node labels.
+func FleetSessionWindows(sessions map[string][]node.Session, windows []config.Window, now time.Time) {
+	all := flatten(sessions)
+	top := topCountries(all, config.CountryTopN)
+
+	totals := sessionWindows(all, windows, now, func(code string) string {
+		return foldCountry(code, top)
+	})
+
+	for _, gauge := range []*prometheus.GaugeVec{fleetRecentSessions, fleetRecentSessionTraffic,
+		fleetRecentSessionEarnings, fleetRecentSessionDuration} {
+		gauge.Reset()
+	}
+
+	for k, t := range totals {
+		fleetRecentSessions.WithLabelValues(k.service, k.country, k.window).Set(float64(t.sessions))
+		fleetRecentSessionTraffic.WithLabelValues(k.service, k.country, k.window).Set(t.traffic)
+		fleetRecentSessionEarnings.WithLabelValues(k.service, k.country, k.window).Set(t.earnings)
+		fleetRecentSessionDuration.WithLabelValues(k.service, k.country, k.window).Set(t.duration.Seconds())
+	}
+}
@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
+
+	"github.com/sch8ill/mystprom/config"
+)
+
+var nodeMonitoringStatusState = newGaugeVec(Metric{
+	Name:   "myst_node_monitoring_status_state",
+	Help:   "Monitoring status of the node as a state set, 1 for the current status",
+	Type:   StateSet,
+	Labels: []string{"id", "name", "myst_node_monitoring_status_state"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeIPCategoryState = newGaugeVec(Metric{
+	Name:   "myst_node_ip_category_state",
+	Help:   "IP category of the node as a state set, 1 for the current category",
+	Type:   StateSet,
+	Labels: []string{"id", "name", "myst_node_ip_category_state"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeMonitoringStatusDuration = newGaugeVec(Metric{
+	Name:   "myst_node_monitoring_status_duration_seconds",
+	Help:   "Time the node has been in its current monitoring status since observed by the exporter",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+})
+
+var nodeMonitoringStatusTransitions = newCounterVec(Metric{
+	Name:   "myst_node_monitoring_status_transitions_total",
+	Help:   "Number of monitoring status changes of the node",
+	Labels: []string{"id", "name", "from", "to"},
+	Source: SourceNodes,
+})
+
+var nodeIPCategoryDuration = newGaugeVec(Metric{
+	Name:   "myst_node_ip_category_duration_seconds",
+	Help:   "Time the node has been in its current ip category since observed by the exporter",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+})
+
+var nodeIPCategoryTransitions = newCounterVec(Metric{
+	Name:   "myst_node_ip_category_transitions_total",
+	Help:   "Number of ip category changes of the node",
+	Labels: []string{"id", "name", "from", "to"},
+	Source: SourceNodes,
+})
+
+func init() {
+	registry.MustRegister(nodeMonitoringStatusState, nodeIPCategoryState, nodeMonitoringStatusDuration,
+		nodeMonitoringStatusTransitions, nodeIPCategoryDuration, nodeIPCategoryTransitions)
+}
+
+// The api does not document its states, these are the ones known to occur.
+// Empty states are exported as unknown and states missing here as other.
+var (
+	monitoringStatusStates = []string{"online", "offline", "pending", "passed", "failed"}
+	ipCategoryStates       = []string{"residential", "hosting", "business", "mobile"}
+)
+
+const (
+	unknownState = "unknown"
+	otherState   = "other"
+)
+
+var monitoringStatuses = newStateSet("myst_node_monitoring_status", nodeMonitoringStatus,
+	nodeMonitoringStatusState, nodeMonitoringStatusDuration, nodeMonitoringStatusTransitions, monitoringStatusStates)
+var ipCategories = newStateSet("myst_node_ip_category", nodeIPCategory, nodeIPCategoryState,
+	nodeIPCategoryDuration, nodeIPCategoryTransitions, ipCategoryStates)
+
+// stateSet exports an enum of every node as a state set, with one series per
+// state that is 1 for the current state and 0 for all others. The v1 gauge
+// keeps the state label of the v1 scheme, the v2 gauge is a real state set
+// whose state label is named after the metric.
+type stateSet struct {
+	name        string
+	v1          *prometheus.GaugeVec
+	v2          *prometheus.GaugeVec
+	duration    *prometheus.GaugeVec
+	transitions *prometheus.CounterVec
+
+	states  []string
+	current map[string]nodeState
+	// reported holds the states missing in states that were already logged.
+	reported map[string]bool
+}
+
+type nodeState struct {
+	name  string
+	state string
+	since time.Time
+}
+
+func newStateSet(name string, v1 *prometheus.GaugeVec, v2 *prometheus.GaugeVec, duration *prometheus.GaugeVec,
+	transitions *prometheus.CounterVec, states []string) *stateSet {
+	return &stateSet{
+		name:        name,
+		v1:          v1,
+		v2:          v2,
+		duration:    duration,
+		transitions: transitions,
+		states:      append(slices.Clone(states), unknownState, otherState),
+		current:     make(map[string]nodeState),
+		reported:    make(map[string]bool),
+	}
+}
+
+func (s *stateSet) set(id string, name string, value string, now time.Time) {
+	state := s.state(value)
+
+	previous, ok := s.current[id]
+	switch {
+	case !ok:
+		previous = nodeState{state: state, since: now}
+	case previous.name != name:
+		// the series of the old name would otherwise be exported forever
+		s.delete(id)
+		previous = nodeState{state: state, since: now}
+	case previous.state != state:
+		s.transitions.WithLabelValues(id, name, previous.state, state).Inc()
+		previous = nodeState{state: state, since: now}
+	}
+	previous.name = name
+	s.current[id] = previous
+
+	for _, known := range s.states {
+		if config.MetricsV1 {
+			s.v1.WithLabelValues(id, name, known).Set(boolToFloat(known == state))
+		}
+		if config.MetricsV2 {
+			s.v2.WithLabelValues(id, name, known).Set(boolToFloat(known == state))
+		}
+	}
+	s.duration.WithLabelValues(id, name).Set(now.Sub(previous.since).Seconds())
+}
+
+// state maps the value reported by the api to a state of the set.
+func (s *stateSet) state(value string) string {
+	value = strings.ToLower(value)
+	switch {
+	case value == "":
+		return unknownState
+	case slices.Contains(s.states, value):
+		return value
+	}
+
+	if !s.reported[value] {
+		s.reported[value] = true
+		log.Warn().Str("metric", s.name).Str("state", value).Msg("unknown state exported as other")
+	}
+	return otherState
+}
+
+// delete removes the series of the node.
+func (s *stateSet) delete(id string) {
+	delete(s.current, id)
+	s.v1.DeletePartialMatch(prometheus.Labels{"id": id})
+	s.v2.DeletePartialMatch(prometheus.Labels{"id": id})
+	s.duration.DeletePartialMatch(prometheus.Labels{"id": id})
+	s.transitions.DeletePartialMatch(prometheus.Labels{"id": id})
+}
+
+// retain removes the series of all nodes not in ids.
+func (s *stateSet) retain(ids []string) {
+	for id := range s.current {
+		if !slices.Contains(ids, id) {
+			s.delete(id)
+		}
+	}
+}
+
+// RetainNodeStates removes the state sets of nodes that are no longer returned
+// by the api.
+func RetainNodeStates(ids []string) {
+	monitoringStatuses.retain(ids)
+	ipCategories.retain(ids)
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+)
+
+// Metrics of the v2 scheme that replace v1 metrics. v2 combines the info-style
+// node metrics into myst_node_info and exports traffic, durations and
+// timestamps in base units. Metrics not listed here are part of both schemes.
+
+// bytesPerTotalsTraffic converts the traffic of the totals endpoint to bytes.
+// v1 exports it multiplied by 1024 as gigabytes.
+const bytesPerTotalsTraffic = 1 << 40
+
+var nodeInfo = newGaugeVec(Metric{
+	Name: "myst_node_info",
+	Help: "Information about the node, always 1",
+	Type: Info,
+	Labels: []string{"id", "name", "user_id", "terms_version", "local_ip", "external_ip", "isp", "location", "os", "arch",
+		"version", "launcher_version", "vendor"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeTrafficBytes = newGaugeVec(Metric{
+	Name:   "myst_node_traffic_bytes",
+	Help:   "Traffic transferred by the node over the last 30 days in bytes",
+	Unit:   "bytes",
+	Labels: []string{"id", "name"},
+	Source: SourceTotals,
+	Scheme: SchemeV2,
+})
+
+var nodeTermsAcceptedTimestamp = newGaugeVec(Metric{
+	Name:   "myst_node_terms_accepted_timestamp_seconds",
+	Help:   "Unix time terms were last accepted by the node",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeAvailableTimestamp = newGaugeVec(Metric{
+	Name:   "myst_node_available_timestamp_seconds",
+	Help:   "Unix time the node was last available",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeCreatedTimestamp = newGaugeVec(Metric{
+	Name:   "myst_node_created_timestamp_seconds",
+	Help:   "Unix time the node was created",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeUpdatedTimestamp = newGaugeVec(Metric{
+	Name:   "myst_node_updated_timestamp_seconds",
+	Help:   "Unix time the node was last updated",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeMonitoringFailedLastTimestamp = newGaugeVec(Metric{
+	Name:   "myst_node_monitoring_failed_last_timestamp_seconds",
+	Help:   "Unix time monitoring last failed on the node",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeOnlineLastTimestamp = newGaugeVec(Metric{
+	Name:   "myst_node_online_last_timestamp_seconds",
+	Help:   "Unix time the node was last online",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeStatusCreatedTimestamp = newGaugeVec(Metric{
+	Name:   "myst_node_status_created_timestamp_seconds",
+	Help:   "Unix time the node monitoring record was created",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeStatusUpdatedTimestamp = newGaugeVec(Metric{
+	Name:   "myst_node_status_updated_timestamp_seconds",
+	Help:   "Unix time the node status was last updated",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNodes,
+	Scheme: SchemeV2,
+})
+
+var nodeLastSettlementTimestamp = newGaugeVec(Metric{
+	Name:   "myst_node_last_settlement_timestamp_seconds",
+	Help:   "Unix time a settlement was last detected by node",
+	Unit:   "seconds",
+	Labels: []string{"id", "name"},
+	Source: SourceNode,
+	Scheme: SchemeV2,
+})
+
+var nodeSessionsTrafficBytes = newGaugeVec(Metric{
+	Name:   "myst_node_sessions_traffic_bytes",
+	Help:   "Traffic served by node by service and country over the last 30 days in bytes, generated from session log",
+	Unit:   "bytes",
+	Labels: []string{"id", "name", "service", "country", "continent", "country_name"},
+	Source: SourceSessions,
+	Scheme: SchemeV2,
+})
+
+var nodeSessionsDurationSeconds = newGaugeVec(Metric{
+	Name:   "myst_node_sessions_duration_seconds",
+	Help:   "Total duration of sessions of the node by service and country over the last 30 days",
+	Unit:   "seconds",
+	Labels: []string{"id", "name", "service", "country", "continent", "country_name"},
+	Source: SourceSessions,
+	Scheme: SchemeV2,
+})
+
+var fleetSessionsTrafficBytes = newGaugeVec(Metric{
+	Name:   "myst_fleet_sessions_traffic_bytes",
+	Help:   "Traffic served by all nodes by service and country over the last 30 days in bytes",
+	Unit:   "bytes",
+	Labels: []string{"service", "country", "continent", "country_name"},
+	Source: SourceSessions,
+	Scheme: SchemeV2,
+})
+
+var fleetSessionsDurationSeconds = newGaugeVec(Metric{
+	Name:   "myst_fleet_sessions_duration_seconds",
+	Help:   "Total duration of sessions of all nodes by service and country over the last 30 days",
+	Unit:   "seconds",
+	Labels: []string{"service", "country", "continent", "country_name"},
+	Source: SourceSessions,
+	Scheme: SchemeV2,
+})
+
+var globalTrafficBytes = newOptionalGauge(Metric{
+	Name:   "myst_global_traffic_bytes",
+	Help:   "Global traffic in bytes",
+	Unit:   "bytes",
+	Source: SourceGlobalStats,
+	Scheme: SchemeV2,
+})
+
+var mystPriceUpdatedTimestamp = newOptionalGauge(Metric{
+	Name:   "myst_token_price_updated_timestamp_seconds",
+	Help:   "Unix time the MYST token prices were last updated",
+	Unit:   "seconds",
+	Source: SourcePrices,
+	Scheme: SchemeV2,
+})
+
+func init() {
+	registry.MustRegister(nodeInfo, nodeTrafficBytes, nodeTermsAcceptedTimestamp, nodeAvailableTimestamp,
+		nodeCreatedTimestamp, nodeUpdatedTimestamp, nodeMonitoringFailedLastTimestamp, nodeOnlineLastTimestamp,
+		nodeStatusCreatedTimestamp, nodeStatusUpdatedTimestamp, nodeLastSettlementTimestamp,
+		nodeSessionsTrafficBytes, nodeSessionsDurationSeconds, fleetSessionsTrafficBytes,
+		fleetSessionsDurationSeconds)
+}
+
+func nodeMetricsV2(node node.Node) {
+	// info labels change with updates of the node, so the previous series is removed
+	nodeInfo.DeletePartialMatch(prometheus.Labels{"id": node.Identity})
+	nodeInfo.WithLabelValues(node.Identity, node.Name, node.UserID, node.TermsVersion, node.LocalIP, node.ExternalIP,
+		node.ISP, node.NodeStatus.Location, node.OS, node.Arch, node.Version, node.LauncherVersion, node.Vendor).Set(1)
+
+	setTimestamp(nodeTermsAcceptedTimestamp, node.TermsAcceptedAt, node.Identity, node.Name)
+	setTimestamp(nodeAvailableTimestamp, node.AvailableAt, node.Identity, node.Name)
+	setTimestamp(nodeCreatedTimestamp, &node.CreatedAt, node.Identity, node.Name)
+	setTimestamp(nodeUpdatedTimestamp, &node.UpdatedAt, node.Identity, node.Name)
+	setTimestamp(nodeMonitoringFailedLastTimestamp, node.NodeStatus.MonitoringFailedLastAt, node.Identity, node.Name)
+	setTimestamp(nodeOnlineLastTimestamp, node.NodeStatus.OnlineLastAt, node.Identity, node.Name)
+	setTimestamp(nodeStatusCreatedTimestamp, &node.NodeStatus.CreatedAt, node.Identity, node.Name)
+	setTimestamp(nodeStatusUpdatedTimestamp, &node.NodeStatus.UpdatedAt, node.Identity, node.Name)
+}
@@ -6,18 +6,35 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
 
-	"github.com/sch8ill/mystprom/api/coingecko"
+	"github.com/sch8ill/mystprom/alerts"
 	"github.com/sch8ill/mystprom/api/mystnodes"
+	stats "github.com/sch8ill/mystprom/api/mystnodes/global-stats"
 	"github.com/sch8ill/mystprom/api/mystnodes/node"
 	"github.com/sch8ill/mystprom/api/mystnodes/totals"
 	"github.com/sch8ill/mystprom/config"
+	"github.com/sch8ill/mystprom/digest"
+	"github.com/sch8ill/mystprom/geoip"
+	"github.com/sch8ill/mystprom/ledger"
 	"github.com/sch8ill/mystprom/metrics"
+	"github.com/sch8ill/mystprom/prices"
+	"github.com/sch8ill/mystprom/push"
 )
 
 type Monitor struct {
-	mystApi   *mystnodes.MystAPI
-	coingecko *coingecko.Coingecko
+	mystApi *mystnodes.MystAPI
+	prices  *prices.Aggregator
+	ledger  *ledger.Ledger
+	geo     *geoip.DB
+	alerter *alerts.Alerter
+	digest  *digest.Collector
+	pusher  *push.Pusher
+
+	settlements *settlementTracker
+	sessions    *sessionTracker
+	// pruned is the last time the ledger was pruned.
+	pruned time.Time
 
 	interval time.Duration
 
@@ -25,12 +42,20 @@ type Monitor struct {
 	wg   sync.WaitGroup
 }
 
-func New(mystApi *mystnodes.MystAPI, coingecko *coingecko.Coingecko, interval time.Duration) *Monitor {
+// New creates a monitor. The ledger, geoip database, alerter, digest collector and pusher are optional and may be nil.
+func New(mystApi *mystnodes.MystAPI, prices *prices.Aggregator, ledger *ledger.Ledger, geo *geoip.DB, alerter *alerts.Alerter, digest *digest.Collector, pusher *push.Pusher, interval time.Duration) *Monitor {
 	return &Monitor{
-		mystApi:   mystApi,
-		coingecko: coingecko,
-		interval:  interval,
-		stop:      make(chan struct{}),
+		mystApi:     mystApi,
+		prices:      prices,
+		ledger:      ledger,
+		geo:         geo,
+		alerter:     alerter,
+		digest:      digest,
+		pusher:      pusher,
+		settlements: newSettlementTracker(config.UnsettledLimit, config.UnsettledAfter),
+		sessions:    newSessionTracker(ledger),
+		interval:    interval,
+		stop:        make(chan struct{}),
 	}
 }
 
@@ -54,8 +79,14 @@ func (m *Monitor) run() {
 			return
 
 		default:
+			// prices are updated first, as node earnings are valued in them
+			if err := m.updateMystPrices(); err != nil {
+				log.Warn().Err(err).Msg("failed to update MYST prices")
+			}
 			if err := m.monitorNodes(); err != nil {
 				log.Warn().Err(err).Msg("failed to monitor")
+			} else {
+				metrics.MonitorCycle(time.Now())
 			}
 			if err := m.updateRewardProgram(); err != nil {
 				log.Warn().Err(err).Msg("failed to update reward program")
@@ -63,12 +94,12 @@ func (m *Monitor) run() {
 			if err := m.updateGlobalStats(); err != nil {
 				log.Warn().Err(err).Msg("failed to update global stats")
 			}
+			if m.pusher != nil {
+				m.pusher.Push(time.Now())
+			}
 			if err := m.mystApi.RefreshToken().Save(config.RefreshFile); err != nil {
 				log.Warn().Err(err).Msg("failed to save refresh token")
 			}
-			if err := m.updateMystPrices(); err != nil {
-				log.Warn().Err(err).Msg("failed to update MYST prices")
-			}
 			time.Sleep(m.interval)
 		}
 	}
@@ -98,6 +129,146 @@ func (m *Monitor) monitorNodes() error {
 	}
 
 	submitMetrics(nodes, sessions, lifetimeEarnings, t)
+	if m.geo != nil {
+		m.updateGeoIP(nodes.Nodes)
+	}
+	if m.alerter != nil {
+		m.alerter.Evaluate(nodes.Nodes, time.Now())
+	}
+	if m.digest != nil {
+		m.digest.ObserveNodes(nodes.Nodes)
+		for id, earnings := range lifetimeEarnings {
+			m.digest.ObserveEarnings(id, earnings)
+		}
+	}
+	m.updateSettlements(nodeNames(nodes.Nodes), lifetimeEarnings)
+
+	if err := m.observeSessions(nodeNames(nodes.Nodes), sessions); err != nil {
+		return err
+	}
+
+	if m.ledger != nil {
+		if err := m.updateLedger(nodeNames(nodes.Nodes), lifetimeEarnings); err != nil {
+			return fmt.Errorf("failed to update ledger: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *Monitor) updateSettlements(names map[string]string, lifetimeEarnings map[string]node.LifetimeEarnings) {
+	now := time.Now()
+
+	for id, earnings := range lifetimeEarnings {
+		// continue from the ledger after a restart, so settlements in between are detected
+		if !m.settlements.known(id) && m.ledger != nil {
+			m.restoreSettlements(id, names[id], now)
+		}
+
+		u := m.settlements.update(id, earnings, now)
+		if u.Settled > 0 {
+			log.Info().Str("id", id).Str("name", names[id]).Float64("amount", u.Settled).Msg("Settlement detected")
+		}
+		if u.BecameOverdue {
+			log.Warn().Str("id", id).Str("name", names[id]).Float64("unsettled", earnings.Unsettled).
+				Time("exceeded_since", u.ExceededSince).Msg("unsettled earnings exceeded threshold without settlement")
+		}
+
+		metrics.NodeSettlements(id, names[id], u.Settled, now, u.Overdue)
+	}
+}
+
+// restoreSettlements seeds the settlement tracker with the last ledger record of
+// the node and exports the last settlement recorded in the ledger.
+func (m *Monitor) restoreSettlements(id string, name string, now time.Time) {
+	last, err := m.ledger.Last(id)
+	if err != nil {
+		log.Warn().Err(err).Str("id", id).Msg("failed to get last ledger record")
+		return
+	}
+	if last == nil {
+		return
+	}
+	m.settlements.seed(id, node.LifetimeEarnings{
+		Total:     last.Total,
+		Settled:   last.Settled,
+		Unsettled: last.Unsettled,
+	})
+
+	settlements, err := m.ledger.Settlements(id, time.Time{}, now)
+	if err != nil {
+		log.Warn().Err(err).Str("id", id).Msg("failed to get settlements from ledger")
+		return
+	}
+	if len(settlements) > 0 {
+		metrics.NodeLastSettlement(id, name, settlements[len(settlements)-1].Time)
+	}
+}
+
+// updateGeoIP exports the autonomous system and location of the nodes.
+func (m *Monitor) updateGeoIP(nodes []node.Node) {
+	var infos []geoip.Info
+	for _, n := range nodes {
+		info, err := m.geo.Lookup(n.ExternalIP)
+		if err != nil {
+			log.Debug().Err(err).Str("id", n.Identity).Msg("failed to look up external ip")
+		}
+		metrics.NodeGeoIP(n.Identity, n.Name, info)
+		infos = append(infos, info)
+	}
+	metrics.FleetASNs(infos)
+}
+
+// observeSessions exports the sessions that were not seen in previous cycles.
+func (m *Monitor) observeSessions(names map[string]string, sessions map[string][]node.Session) error {
+	now := time.Now()
+	observed := make(map[string][]node.Session)
+	for id, s := range sessions {
+		o, err := m.sessions.observe(id, now, s)
+		if err != nil {
+			return fmt.Errorf("failed to observe sessions for %s: %w", id, err)
+		}
+		observed[id] = o
+		metrics.NodeNewSessions(id, names[id], o, s)
+		if m.digest != nil {
+			m.digest.ObserveSessions(id, o)
+		}
+	}
+	if config.CountryAggregation == config.FleetAggregation {
+		metrics.FleetNewSessions(observed, sessions)
+	}
+	m.sessions.prune(now, sessions)
+	return nil
+}
+
+func (m *Monitor) updateLedger(names map[string]string, lifetimeEarnings map[string]node.LifetimeEarnings) error {
+	now := time.Now()
+	yesterday := now.In(config.Timezone).AddDate(0, 0, -1)
+
+	for id, earnings := range lifetimeEarnings {
+		if err := m.ledger.Record(id, now, earnings); err != nil {
+			return fmt.Errorf("record earnings for %s: %w", id, err)
+		}
+
+		today, err := m.ledger.Earnings(id, now, config.Timezone)
+		if err != nil {
+			return fmt.Errorf("get earnings for %s: %w", id, err)
+		}
+
+		previous, err := m.ledger.Earnings(id, yesterday, config.Timezone)
+		if err != nil {
+			return fmt.Errorf("get earnings for %s: %w", id, err)
+		}
+
+		metrics.NodeDailyEarnings(id, names[id], today, previous)
+	}
+
+	if config.LedgerRetention > 0 && now.Sub(m.pruned) >= 24*time.Hour {
+		if err := m.ledger.Prune(now.Add(-config.LedgerRetention)); err != nil {
+			return fmt.Errorf("prune ledger: %w", err)
+		}
+		m.pruned = now
+	}
+
 	return nil
 }
 
@@ -109,6 +280,10 @@ func (m *Monitor) getLifetimeEarnings(ids []string) (map[string]node.LifetimeEar
 		if err != nil {
 			return nil, fmt.Errorf("failed to get lifetime earnings for %s: %w", id, err)
 		}
+		if n.LifetimeEarnings == nil {
+			log.Debug().Str("id", id).Msg("node has no lifetime earnings")
+			continue
+		}
 		earningsMap[id] = *n.LifetimeEarnings
 	}
 
@@ -160,25 +335,65 @@ func (m *Monitor) updateRewardProgram() error {
 	}
 
 	metrics.RewardProgram(ranks, points, stats)
+	if m.digest != nil {
+		m.digest.ObserveRanks(ranks)
+	}
+	if config.LeaderboardSize > 0 {
+		metrics.RewardLeaderboard(ranks, config.LeaderboardSize)
+	}
 	return nil
 }
 
 func (m *Monitor) updateGlobalStats() error {
-	stats, err := m.mystApi.GlobalStats()
+	categories := []string{stats.GlobalCategory}
+	for _, category := range config.GlobalStats {
+		if !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+
+	globalStats, err := m.mystApi.GlobalStats(categories)
 	if err != nil {
 		return fmt.Errorf("get global stats: %w", err)
 	}
 
-	metrics.GlobalStats(stats)
+	for _, stat := range globalStats {
+		if stat.Name == stats.GlobalCategory {
+			global, err := stat.Global()
+			if err != nil {
+				return fmt.Errorf("parse global stats: %w", err)
+			}
+			metrics.GlobalStats(global)
+			continue
+		}
+
+		values, err := stat.Values()
+		if err != nil {
+			return fmt.Errorf("parse %s stats: %w", stat.Name, err)
+		}
+		metrics.GlobalCategoryStats(stat.Name, values)
+	}
+
 	return nil
 }
 
 func (m *Monitor) updateMystPrices() error {
-	prices, err := m.coingecko.MystPrices()
+	quote, err := m.prices.Quote()
 	if err != nil {
+		// keep exporting the last known prices, but flag them as stale
+		metrics.MystPricesStale()
 		return err
 	}
-	metrics.MystPrices(prices)
+	metrics.MystPrices(quote)
+	if m.digest != nil {
+		m.digest.ObservePrices(quote.Prices)
+	}
+
+	if m.ledger != nil {
+		if err := m.ledger.RecordPrices(time.Now(), quote.Prices); err != nil {
+			return fmt.Errorf("failed to record prices: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -188,10 +403,20 @@ func submitMetrics(nodes *node.Nodes, sessions map[string][]node.Session, lifeti
 	for _, node := range nodes.Nodes {
 		metrics.NodeMetrics(node)
 	}
+	metrics.RetainNodeStates(listNodeIDs(nodes))
 
 	names := nodeNames(nodes.Nodes)
+	now := time.Now()
 	for id, s := range sessions {
 		metrics.NodeSessions(id, names[id], s)
+		metrics.NodeSessionWindows(id, names[id], s, config.SessionWindows, now)
+		metrics.NodeSessionActivity(id, names[id], s, config.SessionWindows, now, config.Timezone)
+	}
+	metrics.FleetSessionActivity(sessions, config.Timezone)
+
+	if config.CountryAggregation == config.FleetAggregation {
+		metrics.FleetSessions(sessions)
+		metrics.FleetSessionWindows(sessions, config.SessionWindows, now)
 	}
 
 	for id, earnings := range lifetimeEarnings {
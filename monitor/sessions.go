@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+	"github.com/sch8ill/mystprom/ledger"
+)
+
+// sessionWindow is the period the sessions api returns the sessions of.
+const sessionWindow = 30 * 24 * time.Hour
+
+// sessionTracker deduplicates sessions across monitor cycles. Seen sessions are
+// persisted in the ledger if one is configured and only kept in memory otherwise.
+// The sessions of a node in the first cycle are the backlog of the sessions api
+// and only marked as seen.
+type sessionTracker struct {
+	ledger *ledger.Ledger
+	seen   map[string]map[sessionKey]struct{}
+}
+
+// sessionKey identifies a session of a node.
+type sessionKey struct {
+	startedAt time.Time
+	service   string
+	country   string
+}
+
+func newSessionTracker(l *ledger.Ledger) *sessionTracker {
+	return &sessionTracker{
+		ledger: l,
+		seen:   make(map[string]map[sessionKey]struct{}),
+	}
+}
+
+// observe marks the sessions of the node as seen at now and returns the ones
+// that have not been seen before.
+func (t *sessionTracker) observe(id string, now time.Time, sessions []node.Session) ([]node.Session, error) {
+	if t.ledger != nil {
+		return t.ledger.RecordSessions(id, now, sessions)
+	}
+
+	seen, known := t.seen[id]
+	if !known {
+		seen = make(map[sessionKey]struct{})
+		t.seen[id] = seen
+	}
+
+	var observed []node.Session
+	for _, s := range sessions {
+		key := sessionKey{startedAt: s.StartedAt.UTC(), service: s.ServiceType, country: s.ConsumerCountry}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if known {
+			observed = append(observed, s)
+		}
+	}
+
+	return observed, nil
+}
+
+// prune forgets sessions that are too old to be returned by the sessions api
+// again and nodes that are no longer monitored.
+func (t *sessionTracker) prune(now time.Time, sessions map[string][]node.Session) {
+	cutoff := now.Add(-sessionWindow)
+	for id, seen := range t.seen {
+		if _, ok := sessions[id]; !ok {
+			delete(t.seen, id)
+			continue
+		}
+		for key := range seen {
+			if key.startedAt.Before(cutoff) {
+				delete(seen, key)
+			}
+		}
+	}
+}
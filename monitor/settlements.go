@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+)
+
+// settlementTracker detects settlements by diffing the settled earnings of
+// nodes between monitor cycles.
+type settlementTracker struct {
+	previous map[string]node.LifetimeEarnings
+	// exceededSince holds the time the unsettled earnings of a node first
+	// exceeded the threshold without a settlement in between.
+	exceededSince map[string]time.Time
+	// overdue holds the nodes that were overdue in the last cycle.
+	overdue map[string]bool
+
+	threshold float64
+	after     time.Duration
+}
+
+type settlementUpdate struct {
+	// Settled is the settled amount since the last cycle, 0 if nothing was settled.
+	Settled float64
+	// Overdue is set if the unsettled earnings exceeded the threshold for too long.
+	Overdue bool
+	// BecameOverdue is set in the first cycle the node is overdue.
+	BecameOverdue bool
+	// ExceededSince is the time the unsettled earnings exceeded the threshold.
+	ExceededSince time.Time
+}
+
+func newSettlementTracker(threshold float64, after time.Duration) *settlementTracker {
+	return &settlementTracker{
+		previous:      make(map[string]node.LifetimeEarnings),
+		exceededSince: make(map[string]time.Time),
+		overdue:       make(map[string]bool),
+		threshold:     threshold,
+		after:         after,
+	}
+}
+
+// seed sets the previous earnings of a node, unless they are already known.
+func (t *settlementTracker) seed(id string, earnings node.LifetimeEarnings) {
+	if _, ok := t.previous[id]; !ok {
+		t.previous[id] = earnings
+	}
+}
+
+func (t *settlementTracker) known(id string) bool {
+	_, ok := t.previous[id]
+	return ok
+}
+
+func (t *settlementTracker) update(id string, earnings node.LifetimeEarnings, now time.Time) settlementUpdate {
+	var u settlementUpdate
+
+	if previous, ok := t.previous[id]; ok && earnings.Settled > previous.Settled {
+		u.Settled = earnings.Settled - previous.Settled
+		delete(t.exceededSince, id)
+	}
+	t.previous[id] = earnings
+
+	if t.threshold <= 0 || earnings.Unsettled < t.threshold {
+		delete(t.exceededSince, id)
+		delete(t.overdue, id)
+		return u
+	}
+
+	since, ok := t.exceededSince[id]
+	if !ok {
+		since = now
+		t.exceededSince[id] = since
+	}
+	u.ExceededSince = since
+	u.Overdue = now.Sub(since) >= t.after
+	u.BecameOverdue = u.Overdue && !t.overdue[id]
+	t.overdue[id] = u.Overdue
+
+	return u
+}
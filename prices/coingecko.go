@@ -0,0 +1,27 @@
+package prices
+
+import "github.com/sch8ill/mystprom/api/coingecko"
+
+// coingeckoSource adapts the coingecko client to report market data.
+type coingeckoSource struct {
+	*coingecko.Coingecko
+}
+
+func (c *coingeckoSource) MystQuote() (*Quote, error) {
+	market, err := c.MystMarketData()
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &Quote{
+		Source:       c.Name(),
+		Prices:       map[string]float64{coingecko.MystSymbol: 1},
+		Market:       market,
+		MarketSource: c.Name(),
+	}
+	for currency, data := range market {
+		quote.Prices[currency] = data.Price
+	}
+
+	return quote, nil
+}
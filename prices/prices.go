@@ -0,0 +1,184 @@
+// Package prices aggregates MYST token prices from multiple price sources.
+package prices
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/sch8ill/mystprom/api/coingecko"
+	"github.com/sch8ill/mystprom/api/cryptocompare"
+)
+
+type Strategy string
+
+const (
+	// Fallback uses the prices of the first source in order that succeeds.
+	Fallback Strategy = "fallback"
+	// Median queries every source and uses the median price per currency.
+	Median Strategy = "median"
+
+	MedianSource = "median"
+)
+
+var (
+	_ PriceSource  = (*coingecko.Coingecko)(nil)
+	_ PriceSource  = (*cryptocompare.CryptoCompare)(nil)
+	_ MarketSource = (*coingeckoSource)(nil)
+)
+
+// PriceSource provides MYST token prices by quote currency.
+type PriceSource interface {
+	Name() string
+	MystPrices() (map[string]float64, error)
+}
+
+// MarketSource is a PriceSource that also reports market data of the MYST token
+// from the same request as the prices.
+type MarketSource interface {
+	PriceSource
+	MystQuote() (*Quote, error)
+}
+
+// Quote holds MYST token prices by currency and the source they originate from.
+// Market is only set if a source reports market data, MarketSource is the name
+// of that source.
+type Quote struct {
+	Source       string
+	Prices       map[string]float64
+	Market       map[string]coingecko.MarketData
+	MarketSource string
+}
+
+// Options configures the price sources created by NewSources.
+type Options struct {
+	Currencies      []string
+	CoingeckoAPIKey string
+	CoingeckoPro    bool
+}
+
+type Aggregator struct {
+	sources  []PriceSource
+	strategy Strategy
+}
+
+func NewAggregator(strategy Strategy, sources ...PriceSource) (*Aggregator, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("no price sources configured")
+	}
+
+	if strategy != Fallback && strategy != Median {
+		return nil, fmt.Errorf("unknown price aggregation strategy: %s", strategy)
+	}
+
+	return &Aggregator{
+		sources:  sources,
+		strategy: strategy,
+	}, nil
+}
+
+// NewSources creates the price sources with the given names in order.
+func NewSources(names []string, opts Options) ([]PriceSource, error) {
+	var sources []PriceSource
+	for _, name := range names {
+		source, err := newSource(name, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create price source %s: %w", name, err)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// Quote returns the prices according to the aggregation strategy. An error is
+// only returned if every source failed.
+func (a *Aggregator) Quote() (*Quote, error) {
+	if a.strategy == Median {
+		return a.median()
+	}
+	return a.fallback()
+}
+
+func (a *Aggregator) fallback() (*Quote, error) {
+	var errs []error
+	for _, source := range a.sources {
+		quote, err := sourceQuote(source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+			continue
+		}
+		return quote, nil
+	}
+
+	return nil, fmt.Errorf("all price sources failed: %w", errors.Join(errs...))
+}
+
+func (a *Aggregator) median() (*Quote, error) {
+	byCurrency := make(map[string][]float64)
+	var market map[string]coingecko.MarketData
+	var marketSource string
+	var errs []error
+	for _, source := range a.sources {
+		quote, err := sourceQuote(source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+			continue
+		}
+		for currency, price := range quote.Prices {
+			byCurrency[currency] = append(byCurrency[currency], price)
+		}
+		// market data can not be meaningfully combined, the first reported one is used
+		if market == nil && quote.Market != nil {
+			market = quote.Market
+			marketSource = quote.MarketSource
+		}
+	}
+
+	if len(errs) == len(a.sources) {
+		return nil, fmt.Errorf("all price sources failed: %w", errors.Join(errs...))
+	}
+
+	medians := make(map[string]float64)
+	for currency, prices := range byCurrency {
+		medians[currency] = median(prices)
+	}
+
+	return &Quote{Source: MedianSource, Prices: medians, Market: market, MarketSource: marketSource}, nil
+}
+
+func newSource(name string, opts Options) (PriceSource, error) {
+	switch name {
+	case coingecko.Name:
+		c, err := coingecko.NewWithAPIKey(opts.Currencies, opts.CoingeckoAPIKey, opts.CoingeckoPro)
+		if err != nil {
+			return nil, err
+		}
+		return &coingeckoSource{c}, nil
+	case cryptocompare.Name:
+		return cryptocompare.New(opts.Currencies)
+	default:
+		return nil, errors.New("unknown price source")
+	}
+}
+
+func sourceQuote(source PriceSource) (*Quote, error) {
+	if s, ok := source.(MarketSource); ok {
+		return s.MystQuote()
+	}
+
+	prices, err := source.MystPrices()
+	if err != nil {
+		return nil, err
+	}
+	return &Quote{Source: source.Name(), Prices: prices}, nil
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
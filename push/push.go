@@ -0,0 +1,291 @@
+// Package push pushes the metrics after every monitor cycle to a Pushgateway or
+// a Prometheus remote write receiver, for hosts that can not be scraped.
+package push
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog/log"
+
+	"github.com/sch8ill/mystprom/metrics"
+)
+
+const (
+	requestTimeout = time.Second * 30
+	minBackoff     = time.Second
+	maxBackoff     = time.Minute * 5
+)
+
+type Options struct {
+	// Pushgateway and RemoteWrite are the urls to push to, empty urls are disabled.
+	Pushgateway string
+	RemoteWrite string
+	Job         string
+	// Account groups the metrics in the Pushgateway and is added as label to the
+	// remote write series. It is omitted if empty.
+	Account string
+
+	// PushgatewayAuth and RemoteWriteAuth are the credentials of the targets.
+	PushgatewayAuth Auth
+	RemoteWriteAuth Auth
+
+	// QueueSize is the maximum number of snapshots kept for retrying remote writes.
+	// The Pushgateway only keeps the latest snapshot, as every push replaces the
+	// previous one.
+	QueueSize int
+}
+
+// Auth holds the credentials of a target, either basic auth or a bearer token.
+type Auth struct {
+	Username    string
+	Password    string
+	BearerToken string
+}
+
+// target sends snapshots of the metrics.
+type target interface {
+	name() string
+	send(families []*dto.MetricFamily, t time.Time) error
+}
+
+// Pusher gathers the metrics and queues them for every target.
+type Pusher struct {
+	queues []*queue
+	wg     sync.WaitGroup
+	stop   chan struct{}
+}
+
+func New(opts Options) (*Pusher, error) {
+	p := &Pusher{stop: make(chan struct{})}
+	if opts.Pushgateway != "" {
+		c, err := newClient(opts.PushgatewayAuth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Pushgateway credentials: %w", err)
+		}
+		gateway, err := newPushgateway(c, opts.Pushgateway, opts.Job, opts.Account)
+		if err != nil {
+			return nil, err
+		}
+		p.queues = append(p.queues, newQueue(gateway, 1))
+	}
+	if opts.RemoteWrite != "" {
+		c, err := newClient(opts.RemoteWriteAuth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid remote write credentials: %w", err)
+		}
+		remoteWrite, err := newRemoteWrite(c, opts.RemoteWrite, opts.Job, opts.Account)
+		if err != nil {
+			return nil, err
+		}
+		if opts.QueueSize < 1 {
+			return nil, fmt.Errorf("invalid push queue size: %d: must be at least 1", opts.QueueSize)
+		}
+		p.queues = append(p.queues, newQueue(remoteWrite, opts.QueueSize))
+	}
+	return p, nil
+}
+
+func (p *Pusher) Start() {
+	log.Info().Msg("Starting pusher...")
+	for _, q := range p.queues {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			q.run(p.stop)
+		}()
+	}
+}
+
+// Stop stops the pusher. Queued snapshots that were not pushed yet are discarded.
+func (p *Pusher) Stop() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+// Push queues a snapshot of the current metrics for every target.
+func (p *Pusher) Push(t time.Time) {
+	families, err := metrics.Gather()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to gather metrics to push")
+		return
+	}
+
+	for _, q := range p.queues {
+		q.add(snapshot{families: families, time: t})
+	}
+}
+
+type snapshot struct {
+	families []*dto.MetricFamily
+	time     time.Time
+}
+
+// queue retries failed pushes with exponential backoff. If the queue is full,
+// the oldest snapshot is dropped.
+type queue struct {
+	target target
+	size   int
+
+	mu        sync.Mutex
+	snapshots []snapshot
+	notify    chan struct{}
+}
+
+func newQueue(t target, size int) *queue {
+	return &queue{
+		target: t,
+		size:   size,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+func (q *queue) add(s snapshot) {
+	q.mu.Lock()
+	if len(q.snapshots) >= q.size {
+		q.snapshots = q.snapshots[1:]
+		// a queue of a single snapshot only keeps the latest one by design
+		if q.size > 1 {
+			log.Warn().Str("target", q.target.name()).Msg("push queue is full, dropping oldest snapshot")
+		}
+	}
+	q.snapshots = append(q.snapshots, s)
+	metrics.PushQueue(q.target.name(), len(q.snapshots))
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *queue) head() (snapshot, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.snapshots) == 0 {
+		return snapshot{}, false
+	}
+	return q.snapshots[0], true
+}
+
+// remove removes s from the queue, unless it was dropped in the meantime.
+func (q *queue) remove(s snapshot) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.snapshots) > 0 && q.snapshots[0].time.Equal(s.time) {
+		q.snapshots = q.snapshots[1:]
+	}
+	metrics.PushQueue(q.target.name(), len(q.snapshots))
+}
+
+func (q *queue) run(stop chan struct{}) {
+	backoff := minBackoff
+	for {
+		s, ok := q.head()
+		if !ok {
+			select {
+			case <-stop:
+				return
+			case <-q.notify:
+				continue
+			}
+		}
+
+		err := q.target.send(s.families, s.time)
+		if err == nil {
+			q.remove(s)
+			backoff = minBackoff
+			continue
+		}
+
+		metrics.PushFailed(q.target.name())
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && !statusErr.retryable() {
+			log.Warn().Err(err).Str("target", q.target.name()).Msg("push rejected, dropping snapshot")
+			q.remove(s)
+			continue
+		}
+
+		log.Warn().Err(err).Str("target", q.target.name()).Str("retry_in", backoff.String()).Msg("failed to push metrics")
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// client sends requests with the credentials of a single target.
+type client struct {
+	http *http.Client
+	auth Auth
+}
+
+func newClient(auth Auth) (*client, error) {
+	if auth.BearerToken != "" && auth.Username != "" {
+		return nil, fmt.Errorf("basic auth and bearer token are mutually exclusive")
+	}
+	return &client{http: &http.Client{Timeout: requestTimeout}, auth: auth}, nil
+}
+
+func (c *client) do(method string, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if c.auth.Username != "" {
+		req.SetBasicAuth(c.auth.Username, c.auth.Password)
+	}
+	if c.auth.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.auth.BearerToken)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &statusError{code: res.StatusCode, status: res.Status}
+	}
+	return nil
+}
+
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "unexpected status: " + e.status
+}
+
+// retryable reports whether the request may succeed if retried. Client errors
+// other than rate limits are not retried, as the receiver rejected the data.
+func (e *statusError) retryable() bool {
+	return e.code >= 500 || e.code == http.StatusTooManyRequests
+}
+
+func parseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid push url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid push url: %s: scheme must be http or https", u.Redacted())
+	}
+	return u, nil
+}
@@ -0,0 +1,198 @@
+package push
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// standIn is a local http stand-in for a push target that records the
+// requests it receives.
+type standIn struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []request
+	// status makes the stand-in answer requests with the given index with a status code.
+	status map[int]int
+}
+
+type request struct {
+	method string
+	path   string
+	header http.Header
+	body   []byte
+}
+
+func newStandIn(t *testing.T) *standIn {
+	s := &standIn{status: make(map[int]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request: %v", err)
+		}
+
+		index := len(s.requests)
+		s.requests = append(s.requests, request{method: r.Method, path: r.URL.EscapedPath(), header: r.Header.Clone(), body: body})
+		if code, ok := s.status[index]; ok {
+			w.WriteHeader(code)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *standIn) received() []request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]request(nil), s.requests...)
+}
+
+// wait waits until the stand-in received n requests.
+func (s *standIn) wait(t *testing.T, n int) []request {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 10)
+	for time.Now().Before(deadline) {
+		if requests := s.received(); len(requests) >= n {
+			return requests
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	t.Fatalf("got %d requests, want %d", len(s.received()), n)
+	return nil
+}
+
+func testFamilies() []*dto.MetricFamily {
+	return []*dto.MetricFamily{{
+		Name: proto.String("myst_node_online"),
+		Help: proto.String("whether the node is online"),
+		Type: dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{{
+			Label: []*dto.LabelPair{{Name: proto.String("id"), Value: proto.String("0x1")}},
+			Gauge: &dto.Gauge{Value: proto.Float64(1)},
+		}},
+	}}
+}
+
+func newTestRemoteWrite(t *testing.T, url string, auth Auth) *remoteWrite {
+	t.Helper()
+	c, err := newClient(auth)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	r, err := newRemoteWrite(c, url, "mystprom", "")
+	if err != nil {
+		t.Fatalf("failed to create remote write: %v", err)
+	}
+	return r
+}
+
+func TestSeparateAuth(t *testing.T) {
+	gateway := newStandIn(t)
+	remote := newStandIn(t)
+
+	p, err := New(Options{
+		Pushgateway:     gateway.URL,
+		RemoteWrite:     remote.URL,
+		Job:             "mystprom",
+		PushgatewayAuth: Auth{Username: "gateway", Password: "secret"},
+		RemoteWriteAuth: Auth{BearerToken: "token"},
+		QueueSize:       1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create pusher: %v", err)
+	}
+	p.Start()
+	defer p.Stop()
+	p.Push(time.Now())
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("gateway", "secret")
+	if got := gateway.wait(t, 1)[0].header.Get("Authorization"); got != req.Header.Get("Authorization") {
+		t.Errorf("got Pushgateway authorization %q, want basic auth of gateway", got)
+	}
+	if got := remote.wait(t, 1)[0].header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("got remote write authorization %q, want bearer token", got)
+	}
+}
+
+func TestConflictingAuth(t *testing.T) {
+	_, err := New(Options{
+		RemoteWrite:     "http://localhost",
+		RemoteWriteAuth: Auth{Username: "user", BearerToken: "token"},
+		QueueSize:       1,
+	})
+	if err == nil {
+		t.Error("expected an error for basic auth and bearer token")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	s := newStandIn(t)
+	s.status[0] = http.StatusServiceUnavailable
+
+	q := newQueue(newTestRemoteWrite(t, s.URL, Auth{}), 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go q.run(stop)
+
+	at := time.UnixMilli(1_700_000_000_000)
+	q.add(snapshot{families: testFamilies(), time: at})
+
+	requests := s.wait(t, 2)
+	for i, r := range requests {
+		if got := decodeSeries(t, r.body)[0].samples[0].timestamp; got != at.UnixMilli() {
+			t.Errorf("request %d has timestamp %d, want %d", i, got, at.UnixMilli())
+		}
+	}
+	if _, ok := q.head(); ok {
+		t.Error("snapshot is still queued after a successful retry")
+	}
+}
+
+func TestRejectedNotRetried(t *testing.T) {
+	s := newStandIn(t)
+	s.status[0] = http.StatusBadRequest
+
+	q := newQueue(newTestRemoteWrite(t, s.URL, Auth{}), 2)
+	q.add(snapshot{families: testFamilies(), time: time.UnixMilli(1000)})
+	q.add(snapshot{families: testFamilies(), time: time.UnixMilli(2000)})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go q.run(stop)
+
+	requests := s.wait(t, 2)
+	if got := decodeSeries(t, requests[1].body)[0].samples[0].timestamp; got != 2000 {
+		t.Errorf("got timestamp %d after the rejected snapshot, want 2000", got)
+	}
+}
+
+func TestQueueDropsOldest(t *testing.T) {
+	s := newStandIn(t)
+
+	q := newQueue(newTestRemoteWrite(t, s.URL, Auth{}), 2)
+	for _, ms := range []int64{1000, 2000, 3000} {
+		q.add(snapshot{families: testFamilies(), time: time.UnixMilli(ms)})
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go q.run(stop)
+
+	requests := s.wait(t, 2)
+	for i, want := range []int64{2000, 3000} {
+		if got := decodeSeries(t, requests[i].body)[0].samples[0].timestamp; got != want {
+			t.Errorf("request %d has timestamp %d, want %d", i, got, want)
+		}
+	}
+}
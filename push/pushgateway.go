@@ -0,0 +1,68 @@
+package push
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// pushgateway replaces the metrics of its grouping key in a Pushgateway.
+type pushgateway struct {
+	client *client
+	url    string
+}
+
+func newPushgateway(c *client, rawURL string, job string, account string) (*pushgateway, error) {
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if job == "" {
+		return nil, fmt.Errorf("the job of the Pushgateway is required")
+	}
+
+	path := strings.TrimSuffix(u.String(), "/") + "/metrics/" + groupingSegment("job", job)
+	if account != "" {
+		path += "/" + groupingSegment("account", account)
+	}
+	return &pushgateway{client: c, url: path}, nil
+}
+
+func (p *pushgateway) name() string {
+	return "pushgateway"
+}
+
+func (p *pushgateway) send(families []*dto.MetricFamily, _ time.Time) error {
+	format := expfmt.NewFormat(expfmt.TypeProtoDelim)
+
+	var body bytes.Buffer
+	enc := expfmt.NewEncoder(&body, format)
+	for _, family := range families {
+		if err := enc.Encode(family); err != nil {
+			return err
+		}
+	}
+
+	if err := p.client.do(http.MethodPut, p.url, body.Bytes(), map[string]string{
+		"Content-Type": string(format),
+	}); err != nil {
+		return fmt.Errorf("push to Pushgateway: %w", err)
+	}
+	return nil
+}
+
+// groupingSegment encodes a label of the grouping key as url path segment.
+// Values containing slashes are base64 encoded.
+func groupingSegment(name string, value string) string {
+	if strings.Contains(value, "/") {
+		return name + "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+	return name + "/" + url.PathEscape(value)
+}
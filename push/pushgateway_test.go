@@ -0,0 +1,75 @@
+package push
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestPushgateway(t *testing.T) {
+	tests := []struct {
+		account string
+		path    string
+	}{
+		{account: "", path: "/gateway/metrics/job/mystprom"},
+		{account: "home", path: "/gateway/metrics/job/mystprom/account/home"},
+		{account: "a/b", path: "/gateway/metrics/job/mystprom/account@base64/YS9i"},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			s := newStandIn(t)
+			c, err := newClient(Auth{})
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			p, err := newPushgateway(c, s.URL+"/gateway/", "mystprom", test.account)
+			if err != nil {
+				t.Fatalf("failed to create Pushgateway: %v", err)
+			}
+			if err := p.send(testFamilies(), time.Now()); err != nil {
+				t.Fatalf("failed to send: %v", err)
+			}
+
+			req := s.received()[0]
+			if req.method != http.MethodPut || req.path != test.path {
+				t.Errorf("got %s %s, want PUT %s", req.method, req.path, test.path)
+			}
+			format := expfmt.NewFormat(expfmt.TypeProtoDelim)
+			if got := req.header.Get("Content-Type"); got != string(format) {
+				t.Errorf("got Content-Type %q, want %q", got, format)
+			}
+
+			dec := expfmt.NewDecoder(bytes.NewReader(req.body), format)
+			var families []*dto.MetricFamily
+			for {
+				var family dto.MetricFamily
+				if err := dec.Decode(&family); errors.Is(err, io.EOF) {
+					break
+				} else if err != nil {
+					t.Fatalf("failed to decode body: %v", err)
+				}
+				families = append(families, &family)
+			}
+			if len(families) != 1 || families[0].GetName() != "myst_node_online" ||
+				families[0].GetMetric()[0].GetGauge().GetValue() != 1 {
+				t.Errorf("got families %v, want myst_node_online", families)
+			}
+		})
+	}
+}
+
+func TestPushgatewayJobRequired(t *testing.T) {
+	c, err := newClient(Auth{})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if _, err := newPushgateway(c, "http://localhost", "", ""); err == nil {
+		t.Error("expected an error for an empty job")
+	}
+}
@@ -0,0 +1,160 @@
+package push
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWrite sends snapshots with the Prometheus remote write protocol 1.0.
+type remoteWrite struct {
+	client *client
+	url    string
+	// labels are added to every series, like the target labels of a scrape.
+	labels []label
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type timeSeries struct {
+	labels []label
+	value  float64
+}
+
+func newRemoteWrite(c *client, rawURL string, job string, account string) (*remoteWrite, error) {
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &remoteWrite{client: c, url: u.String()}
+	if job != "" {
+		r.labels = append(r.labels, label{name: "job", value: job})
+	}
+	if account != "" {
+		r.labels = append(r.labels, label{name: "account", value: account})
+	}
+	return r, nil
+}
+
+func (r *remoteWrite) name() string {
+	return "remote_write"
+}
+
+func (r *remoteWrite) send(families []*dto.MetricFamily, t time.Time) error {
+	var series []timeSeries
+	for _, family := range families {
+		series = append(series, r.series(family)...)
+	}
+
+	body := snappy.Encode(nil, encodeWriteRequest(series, t.UnixMilli()))
+	if err := r.client.do(http.MethodPost, r.url, body, map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+		"User-Agent":                        "mystprom",
+	}); err != nil {
+		return fmt.Errorf("remote write: %w", err)
+	}
+	return nil
+}
+
+// series converts the metric family into the series of the text exposition
+// format, e.g. histograms into their _bucket, _sum and _count series.
+func (r *remoteWrite) series(family *dto.MetricFamily) []timeSeries {
+	name := family.GetName()
+
+	var series []timeSeries
+	for _, m := range family.GetMetric() {
+		add := func(suffix string, value float64, extra ...label) {
+			labels := []label{{name: "__name__", value: name + suffix}}
+			labels = append(labels, r.labels...)
+			for _, pair := range m.GetLabel() {
+				labels = append(labels, label{name: pair.GetName(), value: pair.GetValue()})
+			}
+			labels = append(labels, extra...)
+			sort.Slice(labels, func(i, j int) bool {
+				return labels[i].name < labels[j].name
+			})
+			series = append(series, timeSeries{labels: labels, value: value})
+		}
+
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			add("", m.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			add("", m.GetGauge().GetValue())
+		case dto.MetricType_HISTOGRAM:
+			h := m.GetHistogram()
+			var hasInf bool
+			for _, b := range h.GetBucket() {
+				hasInf = hasInf || math.IsInf(b.GetUpperBound(), 1)
+				add("_bucket", float64(b.GetCumulativeCount()), label{name: "le", value: formatFloat(b.GetUpperBound())})
+			}
+			if !hasInf {
+				add("_bucket", float64(h.GetSampleCount()), label{name: "le", value: "+Inf"})
+			}
+			add("_sum", h.GetSampleSum())
+			add("_count", float64(h.GetSampleCount()))
+		case dto.MetricType_SUMMARY:
+			s := m.GetSummary()
+			for _, q := range s.GetQuantile() {
+				add("", q.GetValue(), label{name: "quantile", value: formatFloat(q.GetQuantile())})
+			}
+			add("_sum", s.GetSampleSum())
+			add("_count", float64(s.GetSampleCount()))
+		default:
+			add("", m.GetUntyped().GetValue())
+		}
+	}
+	return series
+}
+
+// encodeWriteRequest encodes the series as prometheus.WriteRequest protobuf
+// message with a single sample per series at timestamp.
+func encodeWriteRequest(series []timeSeries, timestamp int64) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(timestamp))
+
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
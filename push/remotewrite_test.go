@@ -0,0 +1,157 @@
+package push
+
+import (
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// wireSeries mirrors prometheus.TimeSeries of the remote write protocol.
+type wireSeries struct {
+	labels  []label
+	samples []wireSample
+}
+
+// wireSample mirrors prometheus.Sample of the remote write protocol.
+type wireSample struct {
+	value     float64
+	timestamp int64
+}
+
+// decodeSeries snappy decodes the body and decodes the prometheus.WriteRequest
+// message independently of encodeWriteRequest, following the field numbers of
+// the remote write protocol 1.0.
+func decodeSeries(t *testing.T, body []byte) []wireSeries {
+	t.Helper()
+	b, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatalf("failed to snappy decode body: %v", err)
+	}
+
+	var series []wireSeries
+	// WriteRequest: repeated TimeSeries timeseries = 1
+	for _, ts := range fields(t, b, 1) {
+		var s wireSeries
+		// TimeSeries: repeated Label labels = 1
+		for _, l := range fields(t, ts, 1) {
+			// Label: string name = 1, string value = 2
+			s.labels = append(s.labels, label{name: string(fields(t, l, 1)[0]), value: string(fields(t, l, 2)[0])})
+		}
+		// TimeSeries: repeated Sample samples = 2
+		for _, sample := range fields(t, ts, 2) {
+			// Sample: double value = 1, int64 timestamp = 2
+			s.samples = append(s.samples, wireSample{
+				value:     math.Float64frombits(fixed64(fields(t, sample, 1)[0])),
+				timestamp: int64(varint(fields(t, sample, 2)[0])),
+			})
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+// fields returns the raw values of the field with the given number. Length
+// delimited values are returned without their length prefix.
+func fields(t *testing.T, b []byte, number protowire.Number) [][]byte {
+	t.Helper()
+	var values [][]byte
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("invalid tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			t.Fatalf("invalid field %d: %v", num, protowire.ParseError(m))
+		}
+		if num == number {
+			value := b[:m]
+			if typ == protowire.BytesType {
+				value, _ = protowire.ConsumeBytes(value)
+			}
+			values = append(values, value)
+		}
+		b = b[m:]
+	}
+	return values
+}
+
+func fixed64(b []byte) uint64 {
+	v, _ := protowire.ConsumeFixed64(b)
+	return v
+}
+
+func varint(b []byte) uint64 {
+	v, _ := protowire.ConsumeVarint(b)
+	return v
+}
+
+func TestRemoteWrite(t *testing.T) {
+	s := newStandIn(t)
+	c, err := newClient(Auth{})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	r, err := newRemoteWrite(c, s.URL+"/api/v1/write", "mystprom", "home")
+	if err != nil {
+		t.Fatalf("failed to create remote write: %v", err)
+	}
+
+	families := append(testFamilies(), &dto.MetricFamily{
+		Name: proto.String("myst_session_duration_seconds"),
+		Type: dto.MetricType_HISTOGRAM.Enum(),
+		Metric: []*dto.Metric{{
+			Histogram: &dto.Histogram{
+				SampleCount: proto.Uint64(3),
+				SampleSum:   proto.Float64(90),
+				Bucket:      []*dto.Bucket{{UpperBound: proto.Float64(60), CumulativeCount: proto.Uint64(2)}},
+			},
+		}},
+	})
+	at := time.UnixMilli(1_700_000_000_000)
+	if err := r.send(families, at); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	req := s.received()[0]
+	if req.method != http.MethodPost || req.path != "/api/v1/write" {
+		t.Errorf("got %s %s, want POST /api/v1/write", req.method, req.path)
+	}
+	for key, want := range map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	} {
+		if got := req.header.Get(key); got != want {
+			t.Errorf("got %s %q, want %q", key, got, want)
+		}
+	}
+
+	target := []label{{name: "account", value: "home"}, {name: "job", value: "mystprom"}}
+	histogram := func(name string, extra ...label) []label {
+		labels := append([]label{{name: "__name__", value: name}}, target...)
+		return append(labels, extra...)
+	}
+	sample := func(value float64) []wireSample {
+		return []wireSample{{value: value, timestamp: at.UnixMilli()}}
+	}
+	want := []wireSeries{
+		{labels: []label{{"__name__", "myst_node_online"}, {"account", "home"}, {"id", "0x1"}, {"job", "mystprom"}}, samples: sample(1)},
+		{labels: histogram("myst_session_duration_seconds_bucket", label{"le", "60"}), samples: sample(2)},
+		{labels: histogram("myst_session_duration_seconds_bucket", label{"le", "+Inf"}), samples: sample(3)},
+		{labels: histogram("myst_session_duration_seconds_sum"), samples: sample(90)},
+		{labels: histogram("myst_session_duration_seconds_count"), samples: sample(3)},
+	}
+	if got := decodeSeries(t, req.body); !reflect.DeepEqual(got, want) {
+		t.Errorf("got series\n%+v\nwant\n%+v", got, want)
+	}
+}
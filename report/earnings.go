@@ -0,0 +1,312 @@
+// Package report generates earnings reports from the earnings ledger.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sch8ill/mystprom/ledger"
+)
+
+const MystCurrency = "MYST"
+
+// Earnings is a report of the earnings of all nodes in the ledger, valued at the
+// MYST price recorded on the day of the earnings.
+type Earnings struct {
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	Currency    string            `json:"currency"`
+	Total       float64           `json:"total"`
+	TotalValue  float64           `json:"totalValue"`
+	Nodes       []NodeEarnings    `json:"nodes"`
+	Services    []ServiceEarnings `json:"services"`
+	Settlements []Settlement      `json:"settlements"`
+	// PriceFallbacks are the days without a recorded price, which are valued at
+	// the last price of an earlier day.
+	PriceFallbacks []string `json:"priceFallbacks"`
+	// MissingPrices are the days before the first recorded price, which are not
+	// valued and not included in TotalValue.
+	MissingPrices []string `json:"missingPrices"`
+}
+
+// NodeEarnings are the earnings of a node on a day. The price, price date and
+// value of all earnings and settlements are null on days before the first
+// recorded price.
+type NodeEarnings struct {
+	Date  string   `json:"date"`
+	Node  string   `json:"node"`
+	Myst  float64  `json:"myst"`
+	Price *float64 `json:"price"`
+	// PriceDate is the day the price was recorded on.
+	PriceDate *string  `json:"priceDate"`
+	Value     *float64 `json:"value"`
+}
+
+// ServiceEarnings are generated from the session log, sessions are accounted to
+// the day they started on.
+type ServiceEarnings struct {
+	Date      string   `json:"date"`
+	Node      string   `json:"node"`
+	Service   string   `json:"service"`
+	Myst      float64  `json:"myst"`
+	Price     *float64 `json:"price"`
+	PriceDate *string  `json:"priceDate"`
+	Value     *float64 `json:"value"`
+}
+
+type Settlement struct {
+	Time      time.Time `json:"time"`
+	Node      string    `json:"node"`
+	Myst      float64   `json:"myst"`
+	Price     *float64  `json:"price"`
+	PriceDate *string   `json:"priceDate"`
+	Value     *float64  `json:"value"`
+}
+
+type generator struct {
+	ledger   *ledger.Ledger
+	currency string
+	loc      *time.Location
+	prices   map[time.Time]price
+}
+
+// price is the MYST price used for a day and the day it was recorded on. Days
+// before the first recorded price have none.
+type price struct {
+	value float64
+	date  time.Time
+	none  bool
+}
+
+// valued returns the price, the day it was recorded on and the value of amount,
+// which are nil without a price.
+func (p price) valued(amount float64) (*float64, *string, *float64) {
+	if p.none {
+		return nil, nil, nil
+	}
+	date := p.date.Format(time.DateOnly)
+	value := amount * p.value
+	return &p.value, &date, &value
+}
+
+// EarningsReport creates a report of the earnings between the days of from and
+// to in loc, both inclusive.
+func EarningsReport(l *ledger.Ledger, from, to time.Time, currency string, loc *time.Location) (*Earnings, error) {
+	g := &generator{
+		ledger:   l,
+		currency: currency,
+		loc:      loc,
+		prices:   make(map[time.Time]price),
+	}
+
+	start := ledger.StartOfDay(from, loc)
+	end := ledger.StartOfDay(to, loc).AddDate(0, 0, 1)
+
+	report := &Earnings{
+		From:           start.Format(time.DateOnly),
+		To:             end.AddDate(0, 0, -1).Format(time.DateOnly),
+		Currency:       currency,
+		Nodes:          []NodeEarnings{},
+		Services:       []ServiceEarnings{},
+		Settlements:    []Settlement{},
+		PriceFallbacks: []string{},
+		MissingPrices:  []string{},
+	}
+
+	ids, err := l.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if err := g.nodeEarnings(report, id, start, end); err != nil {
+			return nil, fmt.Errorf("node %s: %w", id, err)
+		}
+		if err := g.serviceEarnings(report, id, start, end); err != nil {
+			return nil, fmt.Errorf("node %s: %w", id, err)
+		}
+		if err := g.settlements(report, id, start, end); err != nil {
+			return nil, fmt.Errorf("node %s: %w", id, err)
+		}
+	}
+
+	for date, p := range g.prices {
+		switch {
+		case p.none:
+			report.MissingPrices = append(report.MissingPrices, date.Format(time.DateOnly))
+		case !p.date.Equal(date):
+			report.PriceFallbacks = append(report.PriceFallbacks, date.Format(time.DateOnly))
+		}
+	}
+	sort.Strings(report.PriceFallbacks)
+	sort.Strings(report.MissingPrices)
+
+	return report, nil
+}
+
+func (g *generator) nodeEarnings(report *Earnings, id string, start, end time.Time) error {
+	days, err := g.ledger.DailyEarnings(id, start, end.AddDate(0, 0, -1), g.loc)
+	if err != nil {
+		return err
+	}
+
+	for _, day := range days {
+		p, err := g.dayPrice(day.Date)
+		if err != nil {
+			return err
+		}
+
+		e := NodeEarnings{Date: day.Date.Format(time.DateOnly), Node: id, Myst: day.Earnings}
+		e.Price, e.PriceDate, e.Value = p.valued(day.Earnings)
+		report.Nodes = append(report.Nodes, e)
+		report.Total += day.Earnings
+		if e.Value != nil {
+			report.TotalValue += *e.Value
+		}
+	}
+	return nil
+}
+
+func (g *generator) serviceEarnings(report *Earnings, id string, start, end time.Time) error {
+	sessions, err := g.ledger.Sessions(id, start, end)
+	if err != nil {
+		return err
+	}
+
+	type key struct {
+		date    time.Time
+		service string
+	}
+
+	var keys []key
+	earnings := make(map[key]float64)
+	for _, s := range sessions {
+		k := key{date: ledger.StartOfDay(s.StartedAt, g.loc), service: s.ServiceType}
+		if _, ok := earnings[k]; !ok {
+			keys = append(keys, k)
+		}
+		earnings[k] += s.Earning
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].date.Equal(keys[j].date) {
+			return keys[i].service < keys[j].service
+		}
+		return keys[i].date.Before(keys[j].date)
+	})
+
+	for _, k := range keys {
+		p, err := g.dayPrice(k.date)
+		if err != nil {
+			return err
+		}
+
+		e := ServiceEarnings{Date: k.date.Format(time.DateOnly), Node: id, Service: k.service, Myst: earnings[k]}
+		e.Price, e.PriceDate, e.Value = p.valued(earnings[k])
+		report.Services = append(report.Services, e)
+	}
+	return nil
+}
+
+func (g *generator) settlements(report *Earnings, id string, start, end time.Time) error {
+	settlements, err := g.ledger.Settlements(id, start, end)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range settlements {
+		p, err := g.dayPrice(ledger.StartOfDay(s.Time, g.loc))
+		if err != nil {
+			return err
+		}
+
+		settlement := Settlement{Time: s.Time.In(g.loc), Node: id, Myst: s.Amount}
+		settlement.Price, settlement.PriceDate, settlement.Value = p.valued(s.Amount)
+		report.Settlements = append(report.Settlements, settlement)
+	}
+	return nil
+}
+
+// dayPrice returns the last MYST price recorded on the given day, or on an
+// earlier day if there is none. Days before the first recorded price have no
+// price.
+func (g *generator) dayPrice(date time.Time) (price, error) {
+	if g.currency == MystCurrency {
+		return price{value: 1, date: date}, nil
+	}
+
+	if p, ok := g.prices[date]; ok {
+		return p, nil
+	}
+
+	value, at, err := g.ledger.Price(g.currency, date.AddDate(0, 0, 1))
+	if errors.Is(err, ledger.ErrNoPrice) {
+		g.prices[date] = price{none: true}
+		return g.prices[date], nil
+	}
+	if err != nil {
+		return price{}, err
+	}
+	p := price{value: value, date: ledger.StartOfDay(at, g.loc)}
+	g.prices[date] = p
+
+	return p, nil
+}
+
+func (e *Earnings) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// WriteCSV writes the report as a single table, the type column distinguishes
+// node, service and settlement rows.
+func (e *Earnings) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"type", "date", "node", "service", "myst", "currency", "price", "price_date", "value"}); err != nil {
+		return err
+	}
+
+	for _, n := range e.Nodes {
+		if err := cw.Write(e.row("node", n.Date, n.Node, "", n.Myst, n.Price, n.PriceDate, n.Value)); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range e.Services {
+		if err := cw.Write(e.row("service", s.Date, s.Node, s.Service, s.Myst, s.Price, s.PriceDate, s.Value)); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range e.Settlements {
+		if err := cw.Write(e.row("settlement", s.Time.Format(time.RFC3339), s.Node, "", s.Myst, s.Price, s.PriceDate, s.Value)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// row formats a row of the csv report, the price columns are empty without a
+// price.
+func (e *Earnings) row(kind, date, node, service string, myst float64, price *float64, priceDate *string, value *float64) []string {
+	row := []string{kind, date, node, service, formatFloat(myst), e.Currency, "", "", ""}
+	if price != nil {
+		row[6], row[7], row[8] = formatFloat(*price), *priceDate, formatFloat(*value)
+	}
+	return row
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,138 @@
+package report
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sch8ill/mystprom/api/mystnodes/node"
+	"github.com/sch8ill/mystprom/ledger"
+)
+
+func openLedger(t *testing.T) *ledger.Ledger {
+	t.Helper()
+	l, err := ledger.Open(filepath.Join(t.TempDir(), "ledger.db"))
+	if err != nil {
+		t.Fatalf("failed to open ledger: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func day(d int, hour int) time.Time {
+	return time.Date(2026, 1, d, hour, 0, 0, 0, time.UTC)
+}
+
+// testLedger records earnings on the first four days of 2026 and EUR prices from
+// the second day on, without a price on the fourth day.
+func testLedger(t *testing.T) *ledger.Ledger {
+	t.Helper()
+	l := openLedger(t)
+	for i, earnings := range []node.LifetimeEarnings{
+		{Total: 10}, {Total: 11}, {Total: 13, Settled: 5}, {Total: 16, Settled: 5},
+	} {
+		if err := l.Record("0x1", day(i+1, 12), earnings); err != nil {
+			t.Fatalf("failed to record earnings: %v", err)
+		}
+	}
+	for d, eur := range map[int]float64{2: 0.1, 3: 0.2} {
+		if err := l.RecordPrices(day(d, 6), map[string]float64{"EUR": eur}); err != nil {
+			t.Fatalf("failed to record prices: %v", err)
+		}
+	}
+	return l
+}
+
+func TestEarningsReport(t *testing.T) {
+	e, err := EarningsReport(testLedger(t), day(1, 0), day(4, 0), "EUR", time.UTC)
+	if err != nil {
+		t.Fatalf("failed to generate report: %v", err)
+	}
+
+	if e.From != "2026-01-01" || e.To != "2026-01-04" {
+		t.Errorf("got range %s - %s, want 2026-01-01 - 2026-01-04", e.From, e.To)
+	}
+	if e.Total != 6 || e.TotalValue != 0.1*1+0.2*2+0.2*3 {
+		t.Errorf("got total %g valued at %g, want 6 valued at 1.1", e.Total, e.TotalValue)
+	}
+	if strings.Join(e.MissingPrices, ",") != "2026-01-01" {
+		t.Errorf("got missing prices %v, want 2026-01-01", e.MissingPrices)
+	}
+	if strings.Join(e.PriceFallbacks, ",") != "2026-01-04" {
+		t.Errorf("got price fallbacks %v, want 2026-01-04", e.PriceFallbacks)
+	}
+
+	if len(e.Nodes) != 4 {
+		t.Fatalf("got %d days of node earnings, want 4", len(e.Nodes))
+	}
+	if first := e.Nodes[0]; first.Price != nil || first.PriceDate != nil || first.Value != nil {
+		t.Errorf("got a price for the day before the first price: %+v", first)
+	}
+	if last := e.Nodes[3]; last.Myst != 3 || *last.Price != 0.2 || *last.PriceDate != "2026-01-03" {
+		t.Errorf("got %+v for the last day, want 3 MYST valued at the price of the previous day", last)
+	}
+
+	if len(e.Settlements) != 1 || e.Settlements[0].Myst != 5 || *e.Settlements[0].Price != 0.2 {
+		t.Errorf("got settlements %+v, want 5 MYST valued at 0.2", e.Settlements)
+	}
+}
+
+func TestEarningsReportMyst(t *testing.T) {
+	e, err := EarningsReport(testLedger(t), day(1, 0), day(4, 0), MystCurrency, time.UTC)
+	if err != nil {
+		t.Fatalf("failed to generate report: %v", err)
+	}
+	if e.TotalValue != e.Total || len(e.MissingPrices) != 0 || len(e.PriceFallbacks) != 0 {
+		t.Errorf("got total %g valued at %g with missing prices %v and fallbacks %v, want the MYST total",
+			e.Total, e.TotalValue, e.MissingPrices, e.PriceFallbacks)
+	}
+}
+
+func TestServiceEarnings(t *testing.T) {
+	l := testLedger(t)
+	sessions := []node.Session{
+		{ServiceType: "wireguard", ConsumerCountry: "DE", Earning: 0.5, StartedAt: day(2, 1)},
+		{ServiceType: "wireguard", ConsumerCountry: "US", Earning: 0.25, StartedAt: day(2, 2)},
+		{ServiceType: "scraping", ConsumerCountry: "DE", Earning: 1, StartedAt: day(3, 1)},
+	}
+	if _, err := l.RecordSessions("0x1", day(3, 12), sessions); err != nil {
+		t.Fatalf("failed to record sessions: %v", err)
+	}
+
+	e, err := EarningsReport(l, day(1, 0), day(4, 0), "EUR", time.UTC)
+	if err != nil {
+		t.Fatalf("failed to generate report: %v", err)
+	}
+	want := []struct {
+		date, service string
+		myst          float64
+	}{{"2026-01-02", "wireguard", 0.75}, {"2026-01-03", "scraping", 1}}
+	if len(e.Services) != len(want) {
+		t.Fatalf("got service earnings %+v, want %+v", e.Services, want)
+	}
+	for i, w := range want {
+		if s := e.Services[i]; s.Date != w.date || s.Service != w.service || s.Myst != w.myst {
+			t.Errorf("got service earnings %+v, want %+v", s, w)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	e, err := EarningsReport(testLedger(t), day(1, 0), day(2, 0), "EUR", time.UTC)
+	if err != nil {
+		t.Fatalf("failed to generate report: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := e.WriteCSV(&buf); err != nil {
+		t.Fatalf("failed to write csv: %v", err)
+	}
+	want := "type,date,node,service,myst,currency,price,price_date,value\n" +
+		"node,2026-01-01,0x1,,0,EUR,,,\n" +
+		"node,2026-01-02,0x1,,1,EUR,0.1,2026-01-02,0.1\n"
+	if buf.String() != want {
+		t.Errorf("got csv\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sch8ill/mystprom/ledger"
+)
+
+// Handler serves earnings reports of the ledger, so reports can be generated
+// while the exporter holds the ledger lock. The query parameters from, to,
+// currency and format correspond to the flags of the report command.
+func Handler(l *ledger.Ledger, loc *time.Location) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		from, to, err := ParseRange(query.Get("from"), query.Get("to"), time.Now(), loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(query.Get("currency")))
+		if currency == "" {
+			currency = MystCurrency
+		}
+
+		format := query.Get("format")
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" && format != "json" {
+			http.Error(w, "unknown format: "+format, http.StatusBadRequest)
+			return
+		}
+
+		e, err := EarningsReport(l, from, to, currency, loc)
+		if err != nil {
+			http.Error(w, "failed to generate report: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if format == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			e.WriteJSON(w)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		e.WriteCSV(w)
+	})
+}
+
+// ParseRange parses the first and last day of a report (YYYY-MM-DD) in loc. An
+// empty from defaults to the start of the year of now, an empty to to now.
+func ParseRange(from, to string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	now = now.In(loc)
+	start, err := parseDate(from, time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc), loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+	}
+
+	end, err := parseDate(to, now, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+	}
+	if ledger.StartOfDay(start, loc).After(ledger.StartOfDay(end, loc)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid range: from %s is after to %s",
+			start.Format(time.DateOnly), end.Format(time.DateOnly))
+	}
+	return start, end, nil
+}
+
+func parseDate(date string, fallback time.Time, loc *time.Location) (time.Time, error) {
+	if date == "" {
+		return fallback, nil
+	}
+	return time.ParseInLocation(time.DateOnly, date, loc)
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler(testLedger(t), time.UTC)
+	tests := []struct {
+		query       string
+		code        int
+		contentType string
+	}{
+		{query: "from=2026-01-01&to=2026-01-04", code: http.StatusOK, contentType: "text/csv; charset=utf-8"},
+		{query: "from=2026-01-01&to=2026-01-04&currency=eur&format=json", code: http.StatusOK,
+			contentType: "application/json"},
+		{query: "from=2026-01-04&to=2026-01-01", code: http.StatusBadRequest},
+		{query: "from=01.01.2026", code: http.StatusBadRequest},
+		{query: "format=xml", code: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report/earnings?"+test.query, nil))
+
+			if rec.Code != test.code {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, test.code, rec.Body)
+			}
+			if test.contentType != "" && rec.Header().Get("Content-Type") != test.contentType {
+				t.Errorf("got Content-Type %q, want %q", rec.Header().Get("Content-Type"), test.contentType)
+			}
+		})
+	}
+}
+
+func TestHandlerJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(testLedger(t), time.UTC).ServeHTTP(rec,
+		httptest.NewRequest(http.MethodGet, "/report/earnings?from=2026-01-01&to=2026-01-04&currency=EUR&format=json", nil))
+
+	var e Earnings
+	if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if e.Currency != "EUR" || len(e.Nodes) != 4 || e.Nodes[0].Price != nil || len(e.MissingPrices) != 1 {
+		t.Errorf("got report %+v, want 4 days in EUR with a missing price on the first", e)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	from, to, err := ParseRange("", "", now, time.UTC)
+	if err != nil || !from.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(now) {
+		t.Errorf("got %s - %s (%v), want the start of the year until now", from, to, err)
+	}
+
+	if _, _, err := ParseRange("2026-01-01", "2026-01-01", now, time.UTC); err != nil {
+		t.Errorf("got %v for a single day, want no error", err)
+	}
+	if _, _, err := ParseRange("2026-01-02", "2026-01-01", now, time.UTC); err == nil {
+		t.Error("expected an error for from after to")
+	}
+}
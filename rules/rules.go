@@ -0,0 +1,202 @@
+// Package rules generates Prometheus alerting and recording rules for the
+// exported metrics.
+package rules
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/sch8ill/mystprom/metrics"
+)
+
+const (
+	DefaultOfflineFor       = time.Minute * 15
+	DefaultQualityThreshold = 1.0
+	DefaultQualityFor       = time.Minute * 30
+	DefaultEarningsStalled  = time.Hour * 24
+	DefaultExporterStale    = time.Minute * 30
+)
+
+// Options holds the thresholds of the alerts.
+type Options struct {
+	// OfflineFor is how long a node or service must be down before alerting.
+	OfflineFor time.Duration
+	// QualityThreshold is the quality below which a node is alerted.
+	QualityThreshold float64
+	QualityFor       time.Duration
+	// EarningsStalled is how long an online node may not earn before alerting.
+	EarningsStalled time.Duration
+	// ExporterStale is how long the exporter may not complete a monitor cycle.
+	ExporterStale time.Duration
+}
+
+// File is a Prometheus rule file.
+type File struct {
+	Groups []Group `yaml:"groups"`
+}
+
+type Group struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is either an alerting or a recording rule.
+type Rule struct {
+	Alert       string            `yaml:"alert,omitempty"`
+	Record      string            `yaml:"record,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+var metricNamePattern = regexp.MustCompile(`\bmyst_[a-z0-9_]+`)
+
+// validate rejects durations that can not be expressed as Prometheus duration.
+// The for durations may be 0 to alert immediately, the windows must be positive.
+func (o Options) validate() error {
+	for _, d := range []struct {
+		name     string
+		duration time.Duration
+		window   bool
+	}{
+		{"offline for", o.OfflineFor, false},
+		{"quality for", o.QualityFor, false},
+		{"earnings stalled", o.EarningsStalled, true},
+		{"exporter stale", o.ExporterStale, true},
+	} {
+		if d.duration < 0 || (d.window && d.duration == 0) || (d.duration > 0 && d.duration < time.Second) {
+			return fmt.Errorf("invalid %s duration: %s: must be at least 1s", d.name, d.duration)
+		}
+	}
+	return nil
+}
+
+// Generate creates the alerting and recording rules. It fails if the options
+// are invalid or a rule references a metric that is not in the metric catalog.
+func Generate(opts Options) (*File, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	f := &File{Groups: []Group{
+		{
+			Name: "mystprom.rules",
+			Rules: []Rule{
+				{
+					Record: "fleet:myst_node_online:ratio",
+					Expr:   "sum(myst_node_online) / count(myst_node_online)",
+				},
+				{
+					Record: "id:myst_node_earnings_lifetime:delta1d",
+					Expr:   "delta(myst_node_earnings_lifetime[1d])",
+				},
+				{
+					Record: "fleet:myst_node_earnings_lifetime:delta1d",
+					Expr:   "sum(id:myst_node_earnings_lifetime:delta1d)",
+				},
+			},
+		},
+		{
+			Name: "mystprom.alerts",
+			Rules: []Rule{
+				alert("MystNodeOffline", "myst_node_online == 0", opts.OfflineFor, "critical",
+					"Node {{ $labels.name }} is offline",
+					"Node {{ $labels.name }} ({{ $labels.id }}) has been offline for more than "+promDuration(opts.OfflineFor)+"."),
+				alert("MystNodeMonitoringFailed", "myst_node_monitoring_failed == 1", opts.OfflineFor, "warning",
+					"Monitoring failed on node {{ $labels.name }}",
+					"The Mysterium monitoring of node {{ $labels.name }} ({{ $labels.id }}) failed."),
+				alert("MystNodeMalicious", "myst_node_malicious == 1", 0, "critical",
+					"Node {{ $labels.name }} is tagged malicious",
+					"Node {{ $labels.name }} ({{ $labels.id }}) is tagged as malicious."),
+				alert("MystNodeQualityLow", fmt.Sprintf("myst_node_quality < %g", opts.QualityThreshold), opts.QualityFor,
+					"warning", "Quality of node {{ $labels.name }} dropped",
+					fmt.Sprintf("The quality of node {{ $labels.name }} is {{ $value }}, below %g.", opts.QualityThreshold)),
+				alert("MystNodeServiceStopped", "myst_node_service == 0", opts.OfflineFor, "warning",
+					"Service {{ $labels.service }} stopped on node {{ $labels.name }}",
+					"The {{ $labels.service }} service of node {{ $labels.name }} ({{ $labels.id }}) is not running."),
+				alert("MystNodeEarningsStalled",
+					fmt.Sprintf("delta(myst_node_earnings_lifetime[%s]) <= 0 and on (id) myst_node_online == 1",
+						promDuration(opts.EarningsStalled)),
+					0, "warning", "Node {{ $labels.name }} stopped earning",
+					"Node {{ $labels.name }} ({{ $labels.id }}) is online, but did not earn for "+
+						promDuration(opts.EarningsStalled)+"."),
+				alert("MystExporterStale",
+					fmt.Sprintf("time() - myst_exporter_last_cycle_timestamp_seconds > %d or absent(myst_exporter_last_cycle_timestamp_seconds)",
+						int(opts.ExporterStale.Seconds())),
+					0, "critical", "mystprom is stale",
+					"mystprom did not monitor the nodes successfully for more than "+promDuration(opts.ExporterStale)+"."),
+			},
+		},
+	}}
+
+	if err := f.checkMetrics(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// WriteYAML writes the rule file as yaml.
+func (f *File) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// checkMetrics ensures the rules only reference exported metrics.
+func (f *File) checkMetrics() error {
+	for _, group := range f.Groups {
+		for _, rule := range group.Rules {
+			for _, name := range metricNamePattern.FindAllString(rule.Expr, -1) {
+				if _, ok := metrics.Lookup(name); !ok {
+					return fmt.Errorf("rule %s%s references unknown metric: %s", rule.Alert, rule.Record, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func alert(name string, expr string, forDuration time.Duration, severity string, summary string, description string) Rule {
+	r := Rule{
+		Alert:  name,
+		Expr:   expr,
+		Labels: map[string]string{"severity": severity},
+		Annotations: map[string]string{
+			"summary":     summary,
+			"description": description,
+		},
+	}
+	if forDuration > 0 {
+		r.For = promDuration(forDuration)
+	}
+	return r
+}
+
+// promDuration formats d as Prometheus duration, e.g. 1h30m. Fractions of a
+// second are dropped.
+func promDuration(d time.Duration) string {
+	if d < time.Second {
+		return "0s"
+	}
+
+	var b strings.Builder
+	for _, unit := range []struct {
+		suffix   string
+		duration time.Duration
+	}{{"d", time.Hour * 24}, {"h", time.Hour}, {"m", time.Minute}, {"s", time.Second}} {
+		if n := d / unit.duration; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, unit.suffix)
+			d -= n * unit.duration
+		}
+	}
+	return b.String()
+}
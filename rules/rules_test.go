@@ -0,0 +1,62 @@
+package rules
+
+import (
+	"testing"
+	"time"
+)
+
+func defaultOptions() Options {
+	return Options{
+		OfflineFor:       DefaultOfflineFor,
+		QualityThreshold: DefaultQualityThreshold,
+		QualityFor:       DefaultQualityFor,
+		EarningsStalled:  DefaultEarningsStalled,
+		ExporterStale:    DefaultExporterStale,
+	}
+}
+
+func TestGenerateOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(o *Options)
+		valid  bool
+	}{
+		{name: "defaults", modify: func(o *Options) {}, valid: true},
+		{name: "alert immediately", modify: func(o *Options) { o.OfflineFor, o.QualityFor = 0, 0 }, valid: true},
+		{name: "negative for", modify: func(o *Options) { o.OfflineFor = -time.Minute }},
+		{name: "sub-second for", modify: func(o *Options) { o.QualityFor = time.Millisecond * 500 }},
+		{name: "zero window", modify: func(o *Options) { o.EarningsStalled = 0 }},
+		{name: "sub-second window", modify: func(o *Options) { o.EarningsStalled = time.Millisecond }},
+		{name: "negative window", modify: func(o *Options) { o.ExporterStale = -time.Hour }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := defaultOptions()
+			test.modify(&opts)
+			_, err := Generate(opts)
+			if test.valid && err != nil {
+				t.Errorf("got error %v, want valid options", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("expected an error for invalid options")
+			}
+		})
+	}
+}
+
+func TestPromDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{duration: 0, want: "0s"},
+		{duration: time.Millisecond * 500, want: "0s"},
+		{duration: time.Second * 90, want: "1m30s"},
+		{duration: time.Hour*25 + time.Millisecond, want: "1d1h"},
+	}
+	for _, test := range tests {
+		if got := promDuration(test.duration); got != test.want {
+			t.Errorf("got %s for %s, want %s", got, test.duration, test.want)
+		}
+	}
+}